@@ -0,0 +1,245 @@
+//Package checkgrp maintains the group of handlers for health checking,
+//they back the liveness and readiness probes of the pod.
+package checkgrp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Mahamadou828/tgs_with_golang/foundation/logger"
+	"github.com/Mahamadou828/tgs_with_golang/foundation/web"
+	"go.uber.org/zap"
+)
+
+//DefaultTimeout is the deadline of a check when no timeout is given
+const DefaultTimeout = 2 * time.Second
+
+//Check is a dependency the service needs to handle requests. A failing
+//critical check makes the service unready, a failing non critical check
+//is only reported.
+type Check struct {
+	Name     string
+	Critical bool
+	Timeout  time.Duration
+	Run      func(ctx context.Context) error
+}
+
+//Pinger is implemented by the database handles such as *sql.DB
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+//PostgresCheck creates a critical check pinging the database.
+func PostgresCheck(db Pinger) Check {
+	return Check{
+		Name:     "postgres",
+		Critical: true,
+		Run:      db.PingContext,
+	}
+}
+
+//SecretsManagerCheck creates a non critical check calling the secrets manager,
+//the run function is usually the StatusCheck of the business/sys/aws/ssm client.
+//The secrets are only read at startup, so an outage of the secrets manager
+//is reported without taking the pods out of the service.
+func SecretsManagerCheck(run func(ctx context.Context) error) Check {
+	return Check{
+		Name: "secretsmanager",
+		Run:  run,
+	}
+}
+
+//Handlers manages the set of check endpoints.
+type Handlers struct {
+	Build string
+
+	//Timeout is the deadline of the checks without their own timeout
+	Timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []Check
+}
+
+//Register adds checks run by the readiness endpoint, it panics on a check
+//without name or run function and on a name already registered, like the
+//registration of a route does.
+func (h *Handlers) Register(checks ...Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, check := range checks {
+		switch {
+		case check.Name == "":
+			panic("checkgrp: check without name")
+		case check.Run == nil:
+			panic(fmt.Sprintf("checkgrp: check %q without run function", check.Name))
+		}
+
+		for _, existing := range h.checks {
+			if existing.Name == check.Name {
+				panic(fmt.Sprintf("checkgrp: check %q already registered", check.Name))
+			}
+		}
+
+		h.checks = append(h.checks, check)
+	}
+}
+
+//checkStatus is the result of a single check.
+type checkStatus struct {
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+//Readiness checks if the dependencies of the service are ready and if not
+//will return a 500 status. The status of every check is always returned so
+//the failing dependency can be found from the probe.
+func (h *Handlers) Readiness(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h.mu.RLock()
+	checks := make([]Check, len(h.checks))
+	copy(checks, h.checks)
+	h.mu.RUnlock()
+
+	results := make(map[string]checkStatus, len(checks))
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	//Every check runs in its own goroutine with its own deadline, so a slow
+	//dependency doesn't delay the others.
+	for _, check := range checks {
+		wg.Add(1)
+
+		go func(check Check) {
+			defer wg.Done()
+
+			result := h.run(ctx, check)
+
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+
+	wg.Wait()
+
+	status := "ok"
+	statusCode := http.StatusOK
+
+	for name, result := range results {
+		if result.Error == "" {
+			continue
+		}
+
+		//A failure is reported on every probe, it's logged as a warning
+		//so it doesn't carry the stack trace of the handlers.
+		logger.FromContext(ctx).Warn("readiness failure",
+			zap.String("check", name),
+			zap.Bool("critical", result.Critical),
+			zap.String("error", result.Error),
+		)
+
+		if result.Critical {
+			status = "down"
+			statusCode = http.StatusInternalServerError
+			continue
+		}
+
+		if status == "ok" {
+			status = "degraded"
+		}
+	}
+
+	data := struct {
+		Status string                 `json:"status"`
+		Checks map[string]checkStatus `json:"checks"`
+	}{
+		Status: status,
+		Checks: results,
+	}
+
+	return web.Respond(ctx, w, data, statusCode)
+}
+
+//run runs a single check with its deadline.
+func (h *Handlers) run(ctx context.Context, check Check) checkStatus {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = h.Timeout
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	//A check ignoring the context can't hold the probe past its deadline.
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Run(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := checkStatus{
+		Status:   "ok",
+		Critical: check.Critical,
+		Duration: time.Since(start).String(),
+	}
+
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+//Liveness returns simple status info if the service is alive. If the
+//app is deployed to a Kubernetes cluster, it will also return pod, node, and
+//namespace details via the Downward API. The Kubernetes environment variables
+//need to be set within your Pod/Deployment manifest.
+func (h *Handlers) Liveness(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unavailable"
+	}
+
+	data := struct {
+		Status     string `json:"status,omitempty"`
+		Build      string `json:"build,omitempty"`
+		Host       string `json:"host,omitempty"`
+		Pod        string `json:"pod,omitempty"`
+		PodIP      string `json:"podIP,omitempty"`
+		Node       string `json:"node,omitempty"`
+		Namespace  string `json:"namespace,omitempty"`
+		GOMAXPROCS string `json:"GOMAXPROCS,omitempty"`
+	}{
+		Status:     "up",
+		Build:      h.Build,
+		Host:       host,
+		Pod:        os.Getenv("KUBERNETES_PODNAME"),
+		PodIP:      os.Getenv("KUBERNETES_PODIP"),
+		Node:       os.Getenv("KUBERNETES_NODENAME"),
+		Namespace:  os.Getenv("KUBERNETES_NAMESPACE"),
+		GOMAXPROCS: os.Getenv("GOMAXPROCS"),
+	}
+
+	return web.Respond(ctx, w, data, http.StatusOK)
+}
@@ -0,0 +1,72 @@
+//Package buildgrp maintains the group of handlers describing the build
+//of the running service.
+package buildgrp
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"go.uber.org/zap"
+
+	"github.com/Mahamadou828/tgs_with_golang/foundation/web"
+)
+
+//Handlers manages the set of build endpoints.
+type Handlers struct {
+	Build string
+	Log   *zap.Logger
+}
+
+//module is a go module the service was built with.
+type module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Sum     string `json:"sum,omitempty"`
+}
+
+//Info returns the build variable of the service along with the module
+//information embedded in the binary by the go toolchain.
+func (h Handlers) Info(w http.ResponseWriter, r *http.Request) {
+	data := struct {
+		Build     string            `json:"build"`
+		GoVersion string            `json:"goVersion,omitempty"`
+		Path      string            `json:"path,omitempty"`
+		Main      *module           `json:"main,omitempty"`
+		Settings  map[string]string `json:"settings,omitempty"`
+		Deps      []module          `json:"deps,omitempty"`
+	}{
+		Build: h.Build,
+	}
+
+	//The build information is missing when the binary isn't built with
+	//module support, the build variable is still returned.
+	if info, ok := debug.ReadBuildInfo(); ok {
+		data.GoVersion = info.GoVersion
+		data.Path = info.Path
+		data.Main = &module{
+			Path:    info.Main.Path,
+			Version: info.Main.Version,
+			Sum:     info.Main.Sum,
+		}
+
+		data.Settings = make(map[string]string, len(info.Settings))
+		for _, s := range info.Settings {
+			data.Settings[s.Key] = s.Value
+		}
+
+		for _, dep := range info.Deps {
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			data.Deps = append(data.Deps, module{
+				Path:    dep.Path,
+				Version: dep.Version,
+				Sum:     dep.Sum,
+			})
+		}
+	}
+
+	if err := web.Respond(r.Context(), w, data, http.StatusOK); err != nil {
+		h.Log.Error("build info", zap.Error(err))
+	}
+}
@@ -0,0 +1,102 @@
+//Package handlers contains the full set of handler functions and routes
+//supported by the web api.
+package handlers
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"time"
+
+	"github.com/Mahamadou828/tgs_with_golang/app/service/api/handlers/checkgrp"
+	"github.com/Mahamadou828/tgs_with_golang/app/service/api/handlers/debug/buildgrp"
+	"github.com/Mahamadou828/tgs_with_golang/business/web/mid"
+	"github.com/Mahamadou828/tgs_with_golang/foundation/logger"
+	"github.com/Mahamadou828/tgs_with_golang/foundation/web"
+	"go.uber.org/zap"
+)
+
+//DebugStandardLibraryMux registers all the debug routes from the standard library
+//into a new mux bypassing the use of the DefaultServerMux. Using the
+//DefaultServerMux would be a security risk since a dependency could inject a
+//handler into our service without us knowing it.
+func DebugStandardLibraryMux() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	//Register all the standard library debug endpoints.
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	return mux
+}
+
+//DebugMux registers all the debug standard library routes and then custom
+//debug application routes for the service, the level is the one shared
+//with the logger so it can be changed at runtime.
+func DebugMux(build string, log *zap.Logger, level zap.AtomicLevel) http.Handler {
+	mux := DebugStandardLibraryMux()
+
+	//Register debug build and log level endpoints.
+	bgh := buildgrp.Handlers{
+		Build: build,
+		Log:   log,
+	}
+	mux.HandleFunc("/debug/build", bgh.Info)
+	mux.Handle("/debug/loglevel", logger.NewLevelController(level))
+
+	return mux
+}
+
+//APIMuxConfig contains all the mandatory systems required by handlers.
+type APIMuxConfig struct {
+	Shutdown     chan os.Signal
+	Log          *zap.Logger
+	Build        string
+	Checks       []checkgrp.Check
+	CheckTimeout time.Duration
+
+	//LogExclude are the paths of the requests that aren't logged
+	LogExclude []string
+}
+
+//APIMux constructs an http.Handler with all application routes defined.
+func APIMux(cfg APIMuxConfig) *web.App {
+	app := web.NewApp(
+		cfg.Shutdown,
+		mid.Logger(cfg.Log, cfg.LogExclude...),
+		mid.Errors(cfg.Log),
+		mid.Metrics(),
+		mid.Panics(cfg.Log),
+	)
+
+	//Register the health check endpoints used by the probes of the pod.
+	cgh := checkgrp.Handlers{
+		Build:   cfg.Build,
+		Timeout: cfg.CheckTimeout,
+	}
+	cgh.Register(cfg.Checks...)
+	app.Handle(http.MethodGet, "", "/liveness", cgh.Liveness)
+	app.Handle(http.MethodGet, "", "/readiness", cgh.Readiness)
+
+	v1 := app.Group("v1")
+	v1.Handle(http.MethodGet, "/hello/:name", hello)
+
+	return app
+}
+
+//hello greets the name given in the path.
+func hello(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	data := struct {
+		Message string `json:"message"`
+	}{
+		Message: "Hello, " + web.Param(r, "name"),
+	}
+
+	return web.Respond(ctx, w, data, http.StatusOK)
+}
@@ -1,9 +1,24 @@
 package main
 
 import (
+	"context"
+	"expvar"
 	"fmt"
-	"html"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Mahamadou828/tgs_with_golang/app/service/api/handlers"
+	"github.com/Mahamadou828/tgs_with_golang/app/service/api/handlers/checkgrp"
+	"github.com/Mahamadou828/tgs_with_golang/app/tools/config"
+	"github.com/Mahamadou828/tgs_with_golang/business/sys/aws/retry"
+	"github.com/Mahamadou828/tgs_with_golang/business/sys/aws/session"
+	"github.com/Mahamadou828/tgs_with_golang/business/sys/aws/ssm"
+	"github.com/Mahamadou828/tgs_with_golang/foundation/logger"
+	"go.uber.org/zap"
 )
 
 //The build represent the environment that the current program is running
@@ -11,12 +26,220 @@ import (
 var build = "dev"
 
 func main() {
-	//Simply print a start message with the build and block the programm on a syscall
-	fmt.Println("Starting the program", build)
+	if err := run(); err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	//Cancel every pending call to aws if the service is asked to stop
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	cfg := struct {
+		Log struct {
+			Level              string        `conf:"default:info"`
+			Encoding           string        `conf:"default:"`
+			OutputPaths        string        `conf:"default:stdout"`
+			SamplingInitial    int           `conf:"default:100"`
+			SamplingThereafter int           `conf:"default:100"`
+			DisableStacktrace  bool          `conf:"default:false"`
+			File               string        `conf:"default:"`
+			FileEncoding       string        `conf:"default:json"`
+			FileLevel          string        `conf:"default:"`
+			FileMaxSizeMB      int           `conf:"default:100"`
+			FileMaxAge         time.Duration `conf:"default:168h"`
+			FileMaxBackups     int           `conf:"default:10"`
+			FileCompress       bool          `conf:"default:true"`
+		}
+		Web struct {
+			APIHost         string        `conf:"default:0.0.0.0:3000"`
+			DebugHost       string        `conf:"default:0.0.0.0:4000"`
+			ReadTimeout     time.Duration `conf:"default:5s"`
+			WriteTimeout    time.Duration `conf:"default:10s"`
+			IdleTimeout     time.Duration `conf:"default:120s"`
+			ShutdownTimeout time.Duration `conf:"default:50s"`
+			CheckTimeout    time.Duration `conf:"default:2s"`
+			LogExclude      string        `conf:"default:/liveness,/readiness"`
+		}
+		AWS struct {
+			Region          string        `conf:"default:"`
+			Profile         string        `conf:"default:"`
+			Endpoint        string        `conf:"default:"`
+			AccessKeyID     string        `conf:"default:"`
+			SecretAccessKey string        `conf:"default:"`
+			SessionToken    string        `conf:"default:"`
+			RoleARN         string        `conf:"default:"`
+			RoleSessionName string        `conf:"default:tgs-api"`
+			ExternalID      string        `conf:"default:"`
+			MaxRetries      int           `conf:"default:5"`
+			HTTPTimeout     time.Duration `conf:"default:10s"`
+			BaseDelay       time.Duration `conf:"default:100ms"`
+			MaxDelay        time.Duration `conf:"default:5s"`
+			MaxElapsedTime  time.Duration `conf:"default:30s"`
+			CallTimeout     time.Duration `conf:"default:10s"`
+			StartupTimeout  time.Duration `conf:"default:1m"`
+		}
+	}{}
+
+	//The aws settings are needed to load the secrets, so a first pass
+	//is made without the secrets store
+	if err := config.Parse(ctx, &cfg, "TGS", nil); err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+
+	//The level is shared with the debug server so it can be changed at runtime
+	level := zap.NewAtomicLevel()
+
+	//The secrets loaded from ssm are registered in the redactor so they never
+	//end up in a log line
+	redactor := logger.NewRedactor()
+
+	log, err := logger.NewLogger("TGS-API", build,
+		logger.WithAtomicLevel(level),
+		logger.WithRedactor(redactor),
+		logger.WithConfig(logger.Config{
+			Level:              cfg.Log.Level,
+			Encoding:           cfg.Log.Encoding,
+			OutputPaths:        cfg.Log.OutputPaths,
+			SamplingInitial:    cfg.Log.SamplingInitial,
+			SamplingThereafter: cfg.Log.SamplingThereafter,
+			DisableStacktrace:  cfg.Log.DisableStacktrace,
+			File:               cfg.Log.File,
+			FileEncoding:       cfg.Log.FileEncoding,
+			FileLevel:          cfg.Log.FileLevel,
+			FileMaxSizeMB:      cfg.Log.FileMaxSizeMB,
+			FileMaxAge:         cfg.Log.FileMaxAge,
+			FileMaxBackups:     cfg.Log.FileMaxBackups,
+			FileCompress:       cfg.Log.FileCompress,
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("constructing logger: %w", err)
+	}
+	defer log.Sync()
+
+	log.Info("starting service")
+
+	//Publish the number of redacted values with the other expvar metrics
+	expvar.Publish("redactions", expvar.Func(func() any {
+		return redactor.Count()
+	}))
+
+	//Start the debug service, it's isolated from the public api
+	debugMux := handlers.DebugMux(build, log, level)
+
+	go func() {
+		log.Info("debug server started", zap.String("host", cfg.Web.DebugHost))
+		if err := http.ListenAndServe(cfg.Web.DebugHost, debugMux); err != nil {
+			log.Error("debug server closed", zap.String("host", cfg.Web.DebugHost), zap.Error(err))
+		}
+	}()
+
+	startupCtx, cancel := context.WithTimeout(ctx, cfg.AWS.StartupTimeout)
+	defer cancel()
+
+	//Construct the aws session shared by all the aws clients
+	sess, err := session.New(startupCtx, session.Config{
+		Region:          cfg.AWS.Region,
+		Profile:         cfg.AWS.Profile,
+		Endpoint:        cfg.AWS.Endpoint,
+		AccessKeyID:     cfg.AWS.AccessKeyID,
+		SecretAccessKey: cfg.AWS.SecretAccessKey,
+		SessionToken:    cfg.AWS.SessionToken,
+		RoleARN:         cfg.AWS.RoleARN,
+		RoleSessionName: cfg.AWS.RoleSessionName,
+		ExternalID:      cfg.AWS.ExternalID,
+		HTTPTimeout:     cfg.AWS.HTTPTimeout,
+		Retryer: retry.New(log, retry.Config{
+			MaxRetries:     cfg.AWS.MaxRetries,
+			BaseDelay:      cfg.AWS.BaseDelay,
+			MaxDelay:       cfg.AWS.MaxDelay,
+			MaxElapsedTime: cfg.AWS.MaxElapsedTime,
+		}),
+	})
+	if err != nil {
+		return fmt.Errorf("constructing aws session: %w", err)
+	}
+
+	store := ssm.New(sess, cfg.AWS.CallTimeout)
+
+	loader := func(ctx context.Context) (map[string]string, error) {
+		secrets, err := store.ListSecrets(ctx, "tgs-api", build)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, secret := range secrets {
+			redactor.AddValues(secret)
+		}
+
+		return secrets, nil
+	}
+
+	if err := config.Parse(startupCtx, &cfg, "TGS", loader); err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+
+	//Make a channel to listen for an interrupt or terminate signal from the OS.
+	//Use a buffered channel because the signal package requires it.
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
 
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprintf(w, "Hello, %q", html.EscapeString(r.URL.Path))
+	//Construct the mux for the api calls
+	apiMux := handlers.APIMux(handlers.APIMuxConfig{
+		Shutdown:     shutdown,
+		Log:          log,
+		Build:        build,
+		CheckTimeout: cfg.Web.CheckTimeout,
+		LogExclude:   strings.Split(cfg.Web.LogExclude, ","),
+		Checks: []checkgrp.Check{
+			checkgrp.SecretsManagerCheck(store.StatusCheck),
+		},
 	})
 
-	http.ListenAndServe(":3000", nil)
+	//Construct a server to service the requests against the mux.
+	api := http.Server{
+		Addr:         cfg.Web.APIHost,
+		Handler:      apiMux,
+		ReadTimeout:  cfg.Web.ReadTimeout,
+		WriteTimeout: cfg.Web.WriteTimeout,
+		IdleTimeout:  cfg.Web.IdleTimeout,
+		ErrorLog:     zap.NewStdLog(log),
+	}
+
+	//Make a channel to listen for errors coming from the listener. Use a
+	//buffered channel so the goroutine can exit if we don't collect this error.
+	serverErrors := make(chan error, 1)
+
+	//Start the service listening for api requests.
+	go func() {
+		log.Info("api router started", zap.String("host", api.Addr))
+		serverErrors <- api.ListenAndServe()
+	}()
+
+	//Blocking main and waiting for shutdown.
+	select {
+	case err := <-serverErrors:
+		return fmt.Errorf("server error: %w", err)
+
+	case sig := <-shutdown:
+		log.Info("shutdown started", zap.Stringer("signal", sig), zap.Duration("timeout", cfg.Web.ShutdownTimeout))
+		defer log.Info("shutdown complete", zap.Stringer("signal", sig))
+
+		//Give outstanding requests a deadline for completion, it must be
+		//lower than the terminationGracePeriodSeconds of the pod.
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Web.ShutdownTimeout)
+		defer cancel()
+
+		//Asking listener to shut down and shed load.
+		if err := api.Shutdown(ctx); err != nil {
+			log.Error("graceful shutdown did not complete, closing the connections", zap.Error(err))
+			api.Close()
+			return fmt.Errorf("could not stop server gracefully: %w", err)
+		}
+	}
+
+	return nil
 }
@@ -8,6 +8,7 @@ package config
 */
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -33,7 +34,14 @@ type Tag struct {
 	name  string
 }
 
-func Parse(cfg interface{}, ssmSecrets map[string]string, prefix string) error {
+//SecretsLoader retrieve the secrets to inject inside the configuration,
+//the context should be used to bound the calls to the secrets store.
+type SecretsLoader func(ctx context.Context) (map[string]string, error)
+
+//Parse parses the secrets returned by the loader, the environment variables
+//and the command line arguments into the given struct. A nil loader skips
+//the secret-loading phase.
+func Parse(ctx context.Context, cfg interface{}, prefix string, loader SecretsLoader) error {
 	v := reflect.ValueOf(cfg)
 	if v.Kind() != reflect.Ptr {
 		return ErrInvalidStruct
@@ -41,6 +49,16 @@ func Parse(cfg interface{}, ssmSecrets map[string]string, prefix string) error {
 
 	flags := make(map[string]Flag)
 
+	var ssmSecrets map[string]string
+
+	if loader != nil {
+		secrets, err := loader(ctx)
+		if err != nil {
+			return fmt.Errorf("loading secrets: %w", err)
+		}
+		ssmSecrets = secrets
+	}
+
 	//Insert the aws ssm secret
 	for key, secret := range ssmSecrets {
 		flags[key] = Flag{
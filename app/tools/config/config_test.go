@@ -0,0 +1,49 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Mahamadou828/tgs_with_golang/app/tools/config"
+	"github.com/Mahamadou828/tgs_with_golang/business/sys/aws/awstest"
+	"github.com/Mahamadou828/tgs_with_golang/business/sys/aws/ssm"
+)
+
+func TestParseSecrets(t *testing.T) {
+	srv := awstest.NewServer()
+	defer srv.Close()
+
+	srv.AddSecret("dbpassword", "postgres", map[string]string{"service": "tgs-api", "build": "dev"})
+
+	ctx := context.Background()
+
+	sess, err := srv.Session(ctx)
+	if err != nil {
+		t.Fatalf("creating the session: %s", err)
+	}
+	store := ssm.New(sess, time.Second)
+
+	loader := func(ctx context.Context) (map[string]string, error) {
+		return store.ListSecrets(ctx, "tgs-api", "dev")
+	}
+
+	var cfg struct {
+		DB struct {
+			User       string `conf:"default:admin"`
+			DBPassword string `conf:"default:"`
+		}
+	}
+
+	if err := config.Parse(ctx, &cfg, "TGSTEST", loader); err != nil {
+		t.Fatalf("parsing the config: %s", err)
+	}
+
+	if cfg.DB.DBPassword != "postgres" {
+		t.Errorf("expected the secret postgres in DBPassword, got %q", cfg.DB.DBPassword)
+	}
+
+	if cfg.DB.User != "admin" {
+		t.Errorf("expected the default admin in User, got %q", cfg.DB.User)
+	}
+}
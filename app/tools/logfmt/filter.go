@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// levels gives the severity of each zap level, so a minimum level can be
+// compared with the level of a log.
+var levels = map[string]int{
+	"debug":  0,
+	"info":   1,
+	"warn":   2,
+	"error":  3,
+	"dpanic": 4,
+	"panic":  5,
+	"fatal":  6,
+}
+
+// tsLayouts are the layouts accepted for the ts field and the time flags.
+var tsLayouts = []string{
+	"2006-01-02T15:04:05.000Z0700",
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// condition is a single key=value or key!=value match from --where.
+type condition struct {
+	key    string
+	value  string
+	negate bool
+}
+
+// conditions collects the repeated --where flags.
+type conditions []condition
+
+func (c *conditions) String() string {
+	s := make([]string, len(*c))
+	for i, cond := range *c {
+		op := "="
+		if cond.negate {
+			op = "!="
+		}
+		s[i] = cond.key + op + cond.value
+	}
+	return strings.Join(s, ",")
+}
+
+// Set parses a condition, the key ends at the first = so the value may
+// hold = and != and a ! right before it negates the condition.
+func (c *conditions) Set(v string) error {
+	key, value, ok := strings.Cut(v, "=")
+	negate := strings.HasSuffix(key, "!")
+	key = strings.TrimSuffix(key, "!")
+
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value or key!=value, got %q", v)
+	}
+
+	*c = append(*c, condition{key: key, value: value, negate: negate})
+	return nil
+}
+
+// filter decides which logs are printed.
+type filter struct {
+	service  string
+	trace    string
+	minLevel int
+	since    time.Time
+	until    time.Time
+	where    conditions
+	matchAny bool
+	query    *query
+}
+
+// newFilter validates the filter flags.
+func newFilter(service, trace, level, since, until, match, expr string, where conditions) (filter, error) {
+	f := filter{
+		service:  service,
+		trace:    trace,
+		minLevel: -1,
+		where:    where,
+	}
+
+	if level != "" {
+		lvl, ok := levels[strings.ToLower(level)]
+		if !ok {
+			return filter{}, fmt.Errorf("unknown level %q", level)
+		}
+		f.minLevel = lvl
+	}
+
+	var err error
+	if f.since, err = parseTimeFlag(since); err != nil {
+		return filter{}, fmt.Errorf("parsing --since: %w", err)
+	}
+	if f.until, err = parseTimeFlag(until); err != nil {
+		return filter{}, fmt.Errorf("parsing --until: %w", err)
+	}
+
+	switch match {
+	case "all":
+	case "any":
+		f.matchAny = true
+	default:
+		return filter{}, fmt.Errorf("--match must be all or any, got %q", match)
+	}
+
+	if expr != "" {
+		if f.query, err = compileQuery(expr); err != nil {
+			return filter{}, err
+		}
+	}
+
+	return f, nil
+}
+
+// active reports if any filter was requested. Lines that aren't JSON are
+// only printed when no filter is active.
+func (f filter) active() bool {
+	return f.service != "" || f.trace != "" || f.minLevel >= 0 ||
+		!f.since.IsZero() || !f.until.IsZero() || len(f.where) > 0 || f.query != nil
+}
+
+// match reports if the log passes every filter.
+func (f filter) match(m map[string]any) bool {
+	if f.service != "" && fmt.Sprint(m["service"]) != f.service {
+		return false
+	}
+
+	if f.trace != "" && fmt.Sprint(m[traceIDKey]) != f.trace {
+		return false
+	}
+
+	if f.minLevel >= 0 {
+		lvl, ok := levels[strings.ToLower(fmt.Sprint(m["level"]))]
+		if !ok || lvl < f.minLevel {
+			return false
+		}
+	}
+
+	if !f.since.IsZero() || !f.until.IsZero() {
+		ts, ok := logTime(m)
+		if !ok {
+			return false
+		}
+		if !f.since.IsZero() && ts.Before(f.since) {
+			return false
+		}
+		if !f.until.IsZero() && ts.After(f.until) {
+			return false
+		}
+	}
+
+	if f.query != nil && !f.query.match(m) {
+		return false
+	}
+
+	if len(f.where) == 0 {
+		return true
+	}
+
+	for _, cond := range f.where {
+		v, ok := m[cond.key]
+		matched := ok && fmt.Sprint(v) == cond.value
+		if cond.negate {
+			matched = !matched
+		}
+
+		if f.matchAny && matched {
+			return true
+		}
+		if !f.matchAny && !matched {
+			return false
+		}
+	}
+
+	return !f.matchAny
+}
+
+// logTime parses the ts field of the log.
+func logTime(m map[string]any) (time.Time, bool) {
+	s, ok := m["ts"].(string)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	t, err := parseTime(s)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+// parseTime parses a timestamp in one of the accepted layouts.
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range tsLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unknown time format %q", s)
+}
+
+// parseTimeFlag accepts a timestamp or a duration relative to now,
+// so --since=15m means fifteen minutes ago.
+func parseTimeFlag(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	return parseTime(s)
+}
@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestWhere(t *testing.T) {
+	m, err := decodeLog(`{"msg": "a!=b", "userID": 12345678, "ratio": 0.5, "big": 12345678901234567890}`)
+	if err != nil {
+		t.Fatalf("decoding the log: %s", err)
+	}
+
+	tests := []struct {
+		where string
+		match bool
+	}{
+		{"msg=a!=b", true},
+		{"msg!=a!=b", false},
+		{"msg=a", false},
+		{"msg!=x", true},
+		{"userID=12345678", true},
+		{"userID!=12345678", false},
+		{"ratio=0.5", true},
+		{"big=12345678901234567890", true},
+		{"missing=x", false},
+		{"missing!=x", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.where, func(t *testing.T) {
+			var where conditions
+			if err := where.Set(tt.where); err != nil {
+				t.Fatalf("parsing %s: %s", tt.where, err)
+			}
+
+			f, err := newFilter("", "", "", "", "", "all", "", where)
+			if err != nil {
+				t.Fatalf("creating the filter: %s", err)
+			}
+
+			if got := f.match(m); got != tt.match {
+				t.Errorf("%s: expected %t, got %t", tt.where, tt.match, got)
+			}
+		})
+	}
+}
+
+func TestWhereInvalid(t *testing.T) {
+	for _, v := range []string{"msg", "=x", "!=x"} {
+		var where conditions
+		if err := where.Set(v); err == nil {
+			t.Errorf("%s: expected an error", v)
+		}
+	}
+}
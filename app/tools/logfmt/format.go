@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultTemplate is the layout of the leading columns, every {key} is
+// replaced by the value of the key in the log.
+const defaultTemplate = "{service}: {ts}: {level}: {traceID}: {caller}: {msg}"
+
+// emptyTraceID is printed when a log has no trace id, I like always
+// having a trace id present in the logs.
+const emptyTraceID = "00000000000000000000000000000000"
+
+// levelColors are the ANSI colors of each level, the same as zap's console encoder.
+var levelColors = map[string]string{
+	"debug":  "\x1b[35m",
+	"info":   "\x1b[34m",
+	"warn":   "\x1b[33m",
+	"error":  "\x1b[31m",
+	"dpanic": "\x1b[31;1m",
+	"panic":  "\x1b[31;1m",
+	"fatal":  "\x1b[31;1m",
+}
+
+const colorReset = "\x1b[0m"
+
+var placeholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// segment is either a literal text or a key of the log.
+type segment struct {
+	text  string
+	key   string
+	isKey bool
+}
+
+// formatter turns a log into a single readable line.
+type formatter struct {
+	segments []segment
+	// leading are the keys printed by the template, they are
+	// skipped from the extra fields.
+	leading map[string]bool
+	// expand are the keys printed on their own indented lines,
+	// such as a stack trace.
+	expand []string
+	color  bool
+}
+
+// newFormatter parses the template of the leading columns.
+func newFormatter(tmpl string, expand []string, color bool) (*formatter, error) {
+	f := formatter{
+		leading: make(map[string]bool),
+		expand:  expand,
+		color:   color,
+	}
+
+	last := 0
+	for _, loc := range placeholder.FindAllStringSubmatchIndex(tmpl, -1) {
+		if loc[0] > last {
+			f.segments = append(f.segments, segment{text: tmpl[last:loc[0]]})
+		}
+
+		key := tmpl[loc[2]:loc[3]]
+		f.segments = append(f.segments, segment{key: key, isKey: true})
+		f.leading[key] = true
+		last = loc[1]
+	}
+
+	if last < len(tmpl) {
+		f.segments = append(f.segments, segment{text: tmpl[last:]})
+	}
+
+	if len(f.leading) == 0 {
+		return nil, fmt.Errorf("template %q has no {key} placeholder", tmpl)
+	}
+
+	return &f, nil
+}
+
+// format writes the leading columns followed by the rest of the
+// fields sorted by key.
+func (f *formatter) format(m map[string]any) string {
+	var b strings.Builder
+
+	for _, seg := range f.segments {
+		if !seg.isKey {
+			b.WriteString(seg.text)
+			continue
+		}
+
+		b.WriteString(f.value(m, seg.key))
+	}
+
+	// Add the rest of the keys ignoring the ones we already
+	// added for the log.
+	for _, k := range sortedKeys(m) {
+		if f.leading[k] || f.isExpanded(k) {
+			continue
+		}
+
+		// It's nice to see the key[value] in this format.
+		fmt.Fprintf(&b, ": %s[%v]", k, m[k])
+	}
+
+	b.WriteString(f.expanded(m))
+
+	return b.String()
+}
+
+// isExpanded reports if the key is printed on its own lines.
+func (f *formatter) isExpanded(key string) bool {
+	for _, k := range f.expand {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expanded returns the expanded keys of the log, each one on its own
+// lines indented under the log line.
+func (f *formatter) expanded(m map[string]any) string {
+	var b strings.Builder
+
+	for _, k := range f.expand {
+		v, ok := m[k]
+		if !ok || f.leading[k] {
+			continue
+		}
+
+		s := strings.TrimRight(fmt.Sprint(v), "\n")
+		if s == "" {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n    %s:", k)
+		for _, line := range strings.Split(s, "\n") {
+			b.WriteString("\n        ")
+			b.WriteString(strings.ReplaceAll(line, "\t", "    "))
+		}
+	}
+
+	return b.String()
+}
+
+// value returns the printable value of a leading column.
+func (f *formatter) value(m map[string]any, key string) string {
+	v, ok := m[key]
+
+	switch {
+	case key == traceIDKey && !ok:
+		return emptyTraceID
+	case !ok:
+		return ""
+	}
+
+	s := fmt.Sprint(v)
+
+	if key == "level" && f.color {
+		if c, ok := levelColors[strings.ToLower(s)]; ok {
+			return c + s + colorReset
+		}
+	}
+
+	return s
+}
+
+// sortedKeys returns the keys of the log in a deterministic order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// useColor resolves the --color flag, auto enables the colors when
+// stdout is a terminal and NO_COLOR isn't set.
+func useColor(mode string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if _, ok := os.LookupEnv("NO_COLOR"); ok {
+			return false, nil
+		}
+
+		fi, err := os.Stdout.Stat()
+		if err != nil {
+			return false, nil
+		}
+
+		return fi.Mode()&os.ModeCharDevice != 0, nil
+	}
+
+	return false, fmt.Errorf("--color must be auto, always or never, got %q", mode)
+}
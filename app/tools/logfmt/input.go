@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// errLineTooLong is returned when a line is longer than the maximum size,
+// the line is discarded.
+var errLineTooLong = errors.New("line too long")
+
+// gzipMagic are the first bytes of a gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// lineReader reads lines of any length from a log stream.
+type lineReader struct {
+	name   string
+	r      *bufio.Reader
+	max    int
+	lineNo int
+}
+
+// newLineReader creates a reader of lines, max is the maximum size of a
+// line in bytes, zero means no limit.
+func newLineReader(name string, r io.Reader, max int) *lineReader {
+	return &lineReader{
+		name: name,
+		r:    bufio.NewReaderSize(r, 64*1024),
+		max:  max,
+	}
+}
+
+// next returns the next line without the line ending. A line longer than
+// the max size is discarded and errLineTooLong is returned, io.EOF is
+// returned once the stream is consumed.
+func (lr *lineReader) next() (string, error) {
+	var (
+		line    []byte
+		tooLong bool
+	)
+
+	for {
+		chunk, isPrefix, err := lr.r.ReadLine()
+		if err != nil {
+			if err == io.EOF && (len(line) > 0 || tooLong) {
+				break
+			}
+			return "", err
+		}
+
+		if !tooLong {
+			line = append(line, chunk...)
+			if lr.max > 0 && len(line) > lr.max {
+				line = nil
+				tooLong = true
+			}
+		}
+
+		if !isPrefix {
+			break
+		}
+	}
+
+	lr.lineNo++
+
+	if tooLong {
+		return "", fmt.Errorf("%s:%d: %w, more than %d bytes, skipped", lr.name, lr.lineNo, errLineTooLong, lr.max)
+	}
+
+	return string(line), nil
+}
+
+// openInput opens a log file, - is the standard input. Gzip streams are
+// detected from their first bytes and decompressed.
+func openInput(path string) (io.ReadCloser, error) {
+	var f io.ReadCloser = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		f = file
+	}
+
+	br := bufio.NewReader(f)
+
+	magic, err := br.Peek(len(gzipMagic))
+	if err != nil || !bytes.Equal(magic, gzipMagic) {
+		return readCloser{Reader: br, Closer: f}, nil
+	}
+
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return readCloser{Reader: zr, Closer: f}, nil
+}
+
+// readCloser closes the underlying file of a wrapped reader.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -3,80 +3,280 @@ package main
 
 //@todo understand what this package is doing
 import (
-	"bufio"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strings"
+	"time"
 )
 
-var service string
+// traceIDKey is the key of the trace id written by foundation/logger.
+const traceIDKey = "traceID"
+
+// traceIDAliases are the other spellings of the trace id key, they are
+// renamed to traceIDKey so the trace id is handled the same way everywhere.
+var traceIDAliases = []string{"traceid", "trace_id", "traceId", "TraceID"}
+
+var (
+	service string
+	level   string
+	since   string
+	until   string
+	trace   string
+	match   string
+	where   conditions
+	expr    string
+
+	colorMode  string
+	template   string
+	format     string
+	columns    string
+	expand     string
+	maxLine    int
+	showSource bool
+
+	showStats     bool
+	statsInterval time.Duration
+	top           int
+
+	groupByTrace bool
+)
 
 func init() {
 	flag.StringVar(&service, "service", "", "filter which service to see")
+	flag.StringVar(&level, "level", "", "minimum level to see: debug, info, warn, error...")
+	flag.StringVar(&since, "since", "", "only show logs after a time or a duration ago, e.g. 2022-05-01T10:00:00Z or 15m")
+	flag.StringVar(&until, "until", "", "only show logs before a time or a duration ago")
+	flag.StringVar(&trace, "trace", "", "only show the logs of a single trace id")
+	flag.Var(&where, "where", "only show logs where key=value or key!=value, can be repeated")
+	flag.StringVar(&match, "match", "all", "combine the --where conditions with AND (all) or OR (any)")
+	flag.StringVar(&expr, "query", "", `only show logs matching an expression, e.g. level == "error" && duration > 500ms && path =~ "^/v1/users"`)
+	flag.StringVar(&colorMode, "color", "auto", "color the levels: auto, always or never")
+	flag.StringVar(&template, "template", defaultTemplate, "layout of the leading columns, {key} is replaced by the value of key")
+	flag.StringVar(&format, "format", "text", "output format: text, logfmt, table, csv or json")
+	flag.StringVar(&columns, "columns", defaultColumns, "comma separated columns of the table and csv formats, the leading keys of logfmt")
+	flag.StringVar(&expand, "expand", "error,stacktrace", "comma separated keys printed on their own indented lines by the text and table formats")
+	flag.IntVar(&maxLine, "max-line", 0, "maximum size of a line in bytes, longer lines are skipped, 0 means no limit")
+	flag.BoolVar(&showSource, "source", true, "add the file name as a source column when merging several files")
+	flag.BoolVar(&showStats, "stats", false, "print a summary of the logs instead of the logs")
+	flag.DurationVar(&statsInterval, "stats-interval", 0, "also print the summary periodically, useful when following a stream")
+	flag.IntVar(&top, "top", 10, "number of callers and error messages in the summary")
+	flag.BoolVar(&groupByTrace, "group-by-trace", false, "print the logs grouped by trace id as timelines once the inputs are read")
 }
 
 func main() {
 	flag.Parse()
-	var b strings.Builder
-	// Scan standard input for log data per line.
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		s := scanner.Text()
-		// Convert the JSON to a map for processing.
-		m := make(map[string]any)
-		err := json.Unmarshal([]byte(s), &m)
 
-		if err != nil {
-			if service == "" {
-				fmt.Println(s)
-			}
-			continue
+	log.SetFlags(0)
+	log.SetPrefix("logfmt: ")
+
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "logfmt:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	f, err := newFilter(service, trace, level, since, until, match, expr, where)
+	if err != nil {
+		return err
+	}
+
+	color, err := useColor(colorMode)
+	if err != nil {
+		return err
+	}
+
+	if showStats && groupByTrace {
+		return errors.New("--stats and --group-by-trace can't be used together")
+	}
+
+	paths, err := expandGlobs(flag.Args())
+	if err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	// Several inputs are merged chronologically and each log gets the
+	// name of its input in a source column.
+	merging := len(paths) > 1
+	tmpl, cols := template, splitList(columns)
+	if groupByTrace && tmpl == defaultTemplate {
+		tmpl = traceTemplate
+	}
+	if merging && showSource {
+		if !strings.Contains(tmpl, "{"+sourceKey+"}") {
+			tmpl = "{" + sourceKey + "}: " + tmpl
+		}
+		if !contains(cols, sourceKey) {
+			cols = append([]string{sourceKey}, cols...)
 		}
+	}
+
+	fm, err := newFormatter(tmpl, splitList(expand), color)
+	if err != nil {
+		return err
+	}
+
+	if len(cols) == 0 {
+		return errors.New("--columns requires at least one column")
+	}
 
-		// If a service filter was provided, check.
-		if service != "" && m[service] != service {
+	p, err := newPrinter(os.Stdout, format, fm, cols)
+	if err != nil {
+		return err
+	}
+
+	// A file that can't be read is reported and the next one is processed.
+	failed := false
+
+	var sources []*source
+	for i, path := range paths {
+		src, err := openSource(path, i)
+		if err != nil {
+			log.Println(err)
+			failed = true
 			continue
 		}
-		// I like always having a traceid present in the logs.
-		traceID := "00000000-0000-0000-0000-000000000000"
+		defer src.close()
+
+		sources = append(sources, src)
+	}
+
+	var st *stats
+	if showStats {
+		st = newStats(top)
+
+		if statsInterval > 0 {
+			done := make(chan struct{})
+			defer close(done)
 
-		if v, ok := m["traceID"]; ok {
-			traceID = fmt.Sprintf("%v", v)
+			go func() {
+				ticker := time.NewTicker(statsInterval)
+				defer ticker.Stop()
+
+				for {
+					select {
+					case <-done:
+						return
+					case t := <-ticker.C:
+						fmt.Printf("==== %s\n", t.Format(time.RFC3339))
+						st.print(os.Stdout)
+						fmt.Println()
+					}
+				}
+			}()
 		}
-		// Build out the know portions of the log in the order
-		// I want them in.
-		b.Reset()
-
-		b.WriteString(fmt.Sprintf("%s: %s: %s: %s: %s: %s: ",
-			m["service"],
-			m["ts"],
-			m["level"],
-			traceID,
-			m["caller"],
-			m["msg"],
-		))
-		// Add the rest of the keys ignoring the ones we already
-		// added for the log.
-		for k, v := range m {
-			switch k {
-			case "service", "ts", "level", "traceid", "caller", "msg":
-				continue
+	}
+
+	var tr *traces
+	if groupByTrace {
+		tr = newTraces()
+	}
+
+	emit := func(src *source, e entry) error {
+		if e.m == nil {
+			if f.active() || tr != nil {
+				return nil
+			}
+			if st != nil {
+				st.addRaw()
+				return nil
+			}
+			if merging && showSource {
+				return p.raw(src.name + ": " + e.raw)
 			}
+			return p.raw(e.raw)
+		}
+
+		if !f.match(e.m) {
+			return nil
+		}
+
+		if st != nil {
+			st.add(e.m)
+			return nil
+		}
+
+		if merging && showSource {
+			e.m[sourceKey] = src.name
+		}
+
+		if tr != nil {
+			tr.add(e.m)
+			return nil
+		}
+
+		return p.print(e.m)
+	}
+
+	mergeFailed, err := merge(sources, emit)
+	if err != nil {
+		return err
+	}
+
+	if st != nil {
+		if err := st.print(os.Stdout); err != nil {
+			return err
+		}
+	}
+
+	if tr != nil {
+		if err := tr.print(os.Stdout, fm); err != nil {
+			return err
+		}
+	}
+
+	if err := p.flush(); err != nil {
+		return err
+	}
+
+	if failed || mergeFailed {
+		return errors.New("some inputs could not be read")
+	}
+
+	return nil
+}
 
-			// It's nice to see the key[value] in this format
-			// especially since map ordering is random.
-			b.WriteString(fmt.Sprintf("%s[%v]: ", k, v))
+// normalizeTraceID renames the aliases of the trace id key to traceIDKey.
+func normalizeTraceID(m map[string]any) {
+	for _, alias := range traceIDAliases {
+		v, ok := m[alias]
+		if !ok {
+			continue
+		}
+
+		delete(m, alias)
+		if _, ok := m[traceIDKey]; !ok {
+			m[traceIDKey] = v
 		}
+	}
+}
 
-		// Write the new log format, removing the last :
-		out := b.String()
-		fmt.Println(out[:len(out)-2])
+// splitList splits a comma separated flag, the empty values are ignored.
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		log.Println(err)
+	return list
+}
+
+// contains reports if the list holds the value.
+func contains(list []string, v string) bool {
+	for _, e := range list {
+		if e == v {
+			return true
+		}
 	}
+
+	return false
 }
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sourceKey is the key holding the name of the input of a log when
+// several inputs are merged.
+const sourceKey = "source"
+
+// entry is a single line of an input.
+type entry struct {
+	raw string
+	// m is nil when the line isn't a JSON log.
+	m  map[string]any
+	ts time.Time
+	// src is the position of the input in the arguments, used to keep
+	// the order of the arguments between logs of the same time.
+	src int
+}
+
+// source reads the entries of a single input.
+type source struct {
+	name   string
+	index  int
+	in     io.ReadCloser
+	lr     *lineReader
+	lastTS time.Time
+}
+
+// openSource opens an input, - is the standard input.
+func openSource(path string, index int) (*source, error) {
+	in, err := openInput(path)
+	if err != nil {
+		return nil, err
+	}
+
+	name := sourceName(path)
+
+	s := source{
+		name:  name,
+		index: index,
+		in:    in,
+		lr:    newLineReader(name, in, maxLine),
+	}
+
+	return &s, nil
+}
+
+// next returns the next entry of the input. A line that is too long is
+// reported and skipped, io.EOF is returned once the input is consumed.
+// Lines without a timestamp, like a raw stack trace, get the timestamp of
+// the previous log so they stay next to it.
+func (s *source) next() (entry, error) {
+	for {
+		line, err := s.lr.next()
+		switch {
+		case errors.Is(err, errLineTooLong):
+			log.Println(err)
+			continue
+		case errors.Is(err, io.EOF):
+			return entry{}, err
+		case err != nil:
+			return entry{}, fmt.Errorf("%s:%d: %w", s.name, s.lr.lineNo, err)
+		}
+
+		e := entry{raw: line, ts: s.lastTS, src: s.index}
+
+		m, err := decodeLog(line)
+		if err != nil {
+			return e, nil
+		}
+
+		normalizeTraceID(m)
+		e.m = m
+
+		if ts, ok := logTime(m); ok {
+			e.ts = ts
+			s.lastTS = ts
+		}
+
+		return e, nil
+	}
+}
+
+// decodeLog converts a JSON line to a map for processing. The numbers are
+// kept as json.Number so they print and match as they were written, a
+// float64 would print an id like 12345678 as 1.2345678e+07.
+func decodeLog(line string) (map[string]any, error) {
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+
+	m := make(map[string]any)
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+
+	// A line holding a JSON value followed by text isn't a log.
+	if dec.More() {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+
+	return m, nil
+}
+
+func (s *source) close() error {
+	return s.in.Close()
+}
+
+// sourceName returns the name of an input printed in the source column,
+// the file name without its log extensions, usually the pod name.
+func sourceName(path string) string {
+	if path == "-" {
+		return "stdin"
+	}
+
+	name := filepath.Base(path)
+	for _, ext := range []string{".gz", ".log", ".json", ".txt"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	return name
+}
+
+// expandGlobs replaces the glob patterns by the files they match, a
+// pattern matching nothing is kept so the error is reported when opening it.
+func expandGlobs(args []string) ([]string, error) {
+	var paths []string
+
+	for _, arg := range args {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("bad pattern %q: %w", arg, err)
+		}
+
+		if len(matches) == 0 {
+			paths = append(paths, arg)
+			continue
+		}
+
+		paths = append(paths, matches...)
+	}
+
+	return paths, nil
+}
+
+// =============================================================================
+
+// head is the next entry of a source waiting in the merge heap.
+type head struct {
+	e   entry
+	src *source
+}
+
+// mergeHeap orders the heads by timestamp then by source.
+type mergeHeap []head
+
+func (h mergeHeap) Len() int { return len(h) }
+
+func (h mergeHeap) Less(i, j int) bool {
+	if !h[i].e.ts.Equal(h[j].e.ts) {
+		return h[i].e.ts.Before(h[j].e.ts)
+	}
+	return h[i].e.src < h[j].e.src
+}
+
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *mergeHeap) Push(x any) { *h = append(*h, x.(head)) }
+
+func (h *mergeHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// merge calls fn with the entries of all the sources in chronological order.
+// Every source is expected to be ordered, like the logs of a single pod.
+// A source that fails is reported and dropped from the merge.
+func merge(sources []*source, fn func(src *source, e entry) error) (failed bool, err error) {
+	h := make(mergeHeap, 0, len(sources))
+
+	pull := func(src *source) {
+		e, err := src.next()
+		switch {
+		case errors.Is(err, io.EOF):
+		case err != nil:
+			log.Println(err)
+			failed = true
+		default:
+			heap.Push(&h, head{e: e, src: src})
+		}
+	}
+
+	for _, src := range sources {
+		pull(src)
+	}
+
+	for h.Len() > 0 {
+		hd := heap.Pop(&h).(head)
+
+		if err := fn(hd.src, hd.e); err != nil {
+			return failed, err
+		}
+
+		pull(hd.src)
+	}
+
+	return failed, nil
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// defaultColumns are the columns of the table and csv formats.
+const defaultColumns = "ts,level,service,traceID,caller,msg"
+
+// columnWidths are the initial widths of the well known columns of the
+// table format, so the first lines are aligned with the next ones.
+var columnWidths = map[string]int{
+	"ts":       len("2006-01-02T15:04:05.000Z"),
+	"level":    len("error"),
+	"traceID":  len(emptyTraceID),
+	"service":  len("TGS-API"),
+	"caller":   len("logger/logger.go:100"),
+	"duration": len("1.234567ms"),
+}
+
+// printer writes the logs in one of the output formats.
+type printer interface {
+	// print writes a single log.
+	print(m map[string]any) error
+	// raw writes a line that isn't a JSON log.
+	raw(s string) error
+	// flush writes anything buffered once the input is consumed.
+	flush() error
+}
+
+// newPrinter creates the printer of the --format flag.
+func newPrinter(w io.Writer, format string, fm *formatter, columns []string) (printer, error) {
+	switch format {
+	case "text":
+		return &textPrinter{w: w, fm: fm}, nil
+	case "logfmt":
+		return &logfmtPrinter{w: w, leading: columns}, nil
+	case "table":
+		widths := make([]int, len(columns))
+		for i, c := range columns {
+			widths[i] = columnWidths[c]
+		}
+		return &tablePrinter{w: w, fm: fm, columns: columns, widths: widths}, nil
+	case "csv":
+		return &csvPrinter{w: csv.NewWriter(w), columns: columns}, nil
+	case "json":
+		return &jsonPrinter{w: w}, nil
+	}
+
+	return nil, fmt.Errorf("--format must be text, logfmt, table, csv or json, got %q", format)
+}
+
+// cell returns the printable value of a key, nested values are written in JSON.
+func cell(m map[string]any, key string) string {
+	v, ok := m[key]
+	if !ok || v == nil {
+		return ""
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		// Without exponent so a spreadsheet reads 2500000 and not 2.5e+06.
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]any, []any:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+
+	return fmt.Sprint(v)
+}
+
+// =============================================================================
+
+// textPrinter writes the colon separated format of the formatter.
+type textPrinter struct {
+	w  io.Writer
+	fm *formatter
+}
+
+func (p *textPrinter) print(m map[string]any) error {
+	_, err := fmt.Fprintln(p.w, p.fm.format(m))
+	return err
+}
+
+func (p *textPrinter) raw(s string) error {
+	_, err := fmt.Fprintln(p.w, s)
+	return err
+}
+
+func (p *textPrinter) flush() error {
+	return nil
+}
+
+// =============================================================================
+
+// logfmtPrinter writes key=value pairs, the leading keys first
+// then the rest of the keys sorted.
+type logfmtPrinter struct {
+	w       io.Writer
+	leading []string
+}
+
+func (p *logfmtPrinter) print(m map[string]any) error {
+	var b strings.Builder
+
+	written := make(map[string]bool, len(p.leading))
+	for _, k := range p.leading {
+		if _, ok := m[k]; !ok {
+			continue
+		}
+		writePair(&b, k, cell(m, k))
+		written[k] = true
+	}
+
+	for _, k := range sortedKeys(m) {
+		if written[k] {
+			continue
+		}
+		writePair(&b, k, cell(m, k))
+	}
+
+	_, err := fmt.Fprintln(p.w, b.String())
+	return err
+}
+
+func (p *logfmtPrinter) raw(s string) error {
+	_, err := fmt.Fprintln(p.w, s)
+	return err
+}
+
+func (p *logfmtPrinter) flush() error {
+	return nil
+}
+
+// writePair writes key=value, the value is quoted when needed.
+func writePair(b *strings.Builder, key string, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+
+	b.WriteString(key)
+	b.WriteByte('=')
+
+	if value == "" || strings.ContainsAny(value, " =\"\t\n\\") {
+		b.WriteString(strconv.Quote(value))
+		return
+	}
+
+	b.WriteString(value)
+}
+
+// =============================================================================
+
+// tablePrinter writes the selected columns aligned. The logs are streamed,
+// so a column only grows: earlier lines aren't realigned.
+type tablePrinter struct {
+	w       io.Writer
+	fm      *formatter
+	columns []string
+	widths  []int
+	header  bool
+}
+
+func (p *tablePrinter) print(m map[string]any) error {
+	if !p.header {
+		p.header = true
+
+		header := make([]string, len(p.columns))
+		for i, c := range p.columns {
+			header[i] = strings.ToUpper(c)
+		}
+		if err := p.row(header, nil); err != nil {
+			return err
+		}
+	}
+
+	cells := make([]string, len(p.columns))
+	colored := make([]string, len(p.columns))
+	for i, c := range p.columns {
+		cells[i] = cell(m, c)
+		if c == traceIDKey && cells[i] == "" {
+			cells[i] = emptyTraceID
+		}
+
+		colored[i] = cells[i]
+		if c == "level" && p.fm.color {
+			if code, ok := levelColors[strings.ToLower(cells[i])]; ok {
+				colored[i] = code + cells[i] + colorReset
+			}
+		}
+	}
+
+	if err := p.row(cells, colored); err != nil {
+		return err
+	}
+
+	if s := p.fm.expanded(m); s != "" {
+		_, err := fmt.Fprintln(p.w, strings.TrimPrefix(s, "\n"))
+		return err
+	}
+
+	return nil
+}
+
+// row writes the cells padded to the width of their column, the
+// colored cells are written when given.
+func (p *tablePrinter) row(cells []string, colored []string) error {
+	var b strings.Builder
+
+	for i, c := range cells {
+		if w := len([]rune(c)); w > p.widths[i] {
+			p.widths[i] = w
+		}
+
+		out := c
+		if colored != nil {
+			out = colored[i]
+		}
+		b.WriteString(out)
+
+		// The last column isn't padded
+		if i < len(cells)-1 {
+			b.WriteString(strings.Repeat(" ", p.widths[i]-len([]rune(c))+2))
+		}
+	}
+
+	_, err := fmt.Fprintln(p.w, b.String())
+	return err
+}
+
+func (p *tablePrinter) raw(s string) error {
+	_, err := fmt.Fprintln(p.w, s)
+	return err
+}
+
+func (p *tablePrinter) flush() error {
+	return nil
+}
+
+// =============================================================================
+
+// csvPrinter writes the selected columns as csv with a header line.
+// Lines that aren't JSON are skipped to keep the csv valid.
+type csvPrinter struct {
+	w       *csv.Writer
+	columns []string
+	header  bool
+}
+
+func (p *csvPrinter) print(m map[string]any) error {
+	if !p.header {
+		p.header = true
+		if err := p.w.Write(p.columns); err != nil {
+			return err
+		}
+	}
+
+	record := make([]string, len(p.columns))
+	for i, c := range p.columns {
+		record[i] = cell(m, c)
+	}
+
+	if err := p.w.Write(record); err != nil {
+		return err
+	}
+
+	p.w.Flush()
+	return p.w.Error()
+}
+
+func (p *csvPrinter) raw(s string) error {
+	return nil
+}
+
+func (p *csvPrinter) flush() error {
+	p.w.Flush()
+	return p.w.Error()
+}
+
+// =============================================================================
+
+// jsonPrinter writes every log as indented JSON. Lines that aren't
+// JSON are skipped to keep the output valid.
+type jsonPrinter struct {
+	w io.Writer
+}
+
+func (p *jsonPrinter) print(m map[string]any) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(p.w, string(b))
+	return err
+}
+
+func (p *jsonPrinter) raw(s string) error {
+	return nil
+}
+
+func (p *jsonPrinter) flush() error {
+	return nil
+}
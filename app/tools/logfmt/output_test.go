@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestCell(t *testing.T) {
+	m, err := decodeLog(`{"msg": "m", "userID": 12345678, "bytes": 2.5e6, "ratio": 0.25, "http": {"status": 200}, "ok": true}`)
+	if err != nil {
+		t.Fatalf("decoding the log: %s", err)
+	}
+	m["computed"] = 2500000.0
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"msg", "m"},
+		{"userID", "12345678"},
+		{"bytes", "2.5e6"},
+		{"ratio", "0.25"},
+		{"computed", "2500000"},
+		{"http", `{"status":200}`},
+		{"ok", "true"},
+		{"missing", ""},
+	}
+
+	for _, tt := range tests {
+		if got := cell(m, tt.key); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.key, tt.want, got)
+		}
+	}
+}
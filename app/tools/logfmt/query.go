@@ -0,0 +1,647 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// A query is a boolean expression over the fields of a log, e.g.
+//
+//	level == "error" && duration > 500ms && path =~ "^/v1/users"
+//
+// Fields are bare names, nested fields are joined by dots like http.status
+// and array elements are reached by their index like errors.0. Values are
+// strings in double quotes, numbers, durations like 500ms or 1h30m, true,
+// false and null. The operators are == != < <= > >= for comparisons,
+// =~ !~ for regular expressions, && || ! and parentheses, exists(field)
+// reports if a field is present.
+//
+// A comparison with a missing field is false, except != which is true.
+// Durations compare with the fields written by zap as seconds or as
+// duration strings, strings holding a timestamp compare as times and
+// the level field compares by severity. Booleans and null only support
+// == and !=.
+
+// query is a compiled query evaluated against every log.
+type query struct {
+	root node
+}
+
+// compileQuery parses a query once so it can be evaluated on every line.
+func compileQuery(expr string) (*query, error) {
+	p := parser{lex: lexer{src: expr}}
+	if err := p.advance(); err != nil {
+		return nil, p.error(err)
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, p.error(err)
+	}
+
+	if p.tok.kind != tokEOF {
+		return nil, p.error(fmt.Errorf("unexpected %s", p.tok))
+	}
+
+	return &query{root: root}, nil
+}
+
+// match reports if the log matches the query.
+func (q *query) match(m map[string]any) bool {
+	return q.root.eval(m)
+}
+
+// =============================================================================
+
+// tokenKind is the kind of a token of a query.
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokDuration
+	tokOp
+)
+
+// token is a single token of a query, pos is its byte offset.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokString:
+		return "string " + strconv.Quote(t.text)
+	}
+	return strconv.Quote(t.text)
+}
+
+// operators are the operators of a query, the two characters ones first
+// so they are matched before their prefix.
+var operators = []string{"==", "!=", "<=", ">=", "=~", "!~", "&&", "||", "<", ">", "!", "(", ")"}
+
+// lexer splits a query into tokens.
+type lexer struct {
+	src string
+	pos int
+}
+
+// next returns the next token of the query.
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && unicode.IsSpace(rune(l.src[l.pos])) {
+		l.pos++
+	}
+
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+
+	c := l.src[l.pos]
+
+	switch {
+	case c == '"':
+		return l.string()
+
+	case isDigit(c) || (c == '-' && l.pos+1 < len(l.src) && isDigit(l.src[l.pos+1])):
+		l.pos++
+		for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || l.src[l.pos] == '.') {
+			l.pos++
+		}
+
+		// A number followed by a unit is a duration, like 500ms or 1h30m.
+		kind := tokNumber
+		for l.pos < len(l.src) && (isLetter(l.src[l.pos]) || isDigit(l.src[l.pos]) || l.src[l.pos] == '.') {
+			kind = tokDuration
+			l.pos++
+		}
+
+		return token{kind: kind, text: l.src[start:l.pos], pos: start}, nil
+
+	case isLetter(c):
+		for l.pos < len(l.src) && isIdent(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokIdent, text: l.src[start:l.pos], pos: start}, nil
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			return token{kind: tokOp, text: op, pos: start}, nil
+		}
+	}
+
+	return token{pos: start}, fmt.Errorf("unexpected character %q", c)
+}
+
+// string reads a double quoted string, the escapes are the ones of Go.
+func (l *lexer) string() (token, error) {
+	start := l.pos
+	l.pos++
+
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '\\':
+			l.pos += 2
+			continue
+		case '"':
+			l.pos++
+			s, err := strconv.Unquote(l.src[start:l.pos])
+			if err != nil {
+				return token{pos: start}, fmt.Errorf("invalid string %s", l.src[start:l.pos])
+			}
+			return token{kind: tokString, text: s, pos: start}, nil
+		}
+		l.pos++
+	}
+
+	l.pos = start
+	return token{pos: start}, errors.New("unterminated string")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isIdent(c byte) bool {
+	return isLetter(c) || isDigit(c) || c == '.' || c == '-'
+}
+
+// =============================================================================
+
+// parser builds the tree of a query by recursive descent:
+//
+//	or         = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | primary
+//	primary    = "(" or ")" | "exists" "(" field ")" | comparison
+//	comparison = operand op operand
+type parser struct {
+	lex lexer
+	tok token
+}
+
+// advance reads the next token.
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	p.tok = tok
+	return err
+}
+
+// error points at the position of the current token in the query.
+func (p *parser) error(err error) error {
+	return fmt.Errorf("parsing --query at column %d: %w\n    %s\n    %s^",
+		p.tok.pos+1, err, p.lex.src, strings.Repeat(" ", p.tok.pos))
+}
+
+// isOp reports if the current token is the operator op.
+func (p *parser) isOp(op string) bool {
+	return p.tok.kind == tokOp && p.tok.text == op
+}
+
+// expect consumes the operator op.
+func (p *parser) expect(op string) error {
+	if !p.isOp(op) {
+		return fmt.Errorf("expected %q, got %s", op, p.tok)
+	}
+	return p.advance()
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOp("||") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = orNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOp("&&") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = andNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isOp("!") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return notNode{n: n}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	if p.isOp("(") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		return n, p.expect(")")
+	}
+
+	if p.tok.kind == tokIdent && p.tok.text == "exists" {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+
+		if p.tok.kind != tokIdent {
+			return nil, fmt.Errorf("exists expects a field, got %s", p.tok)
+		}
+		n := existsNode{field: p.tok.text}
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		return n, p.expect(")")
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	op := p.tok.text
+	switch {
+	case p.tok.kind != tokOp:
+		return nil, fmt.Errorf("expected a comparison operator, got %s", p.tok)
+	case op == "==", op == "!=", op == "<", op == "<=", op == ">", op == ">=":
+	case op == "=~", op == "!~":
+	default:
+		return nil, fmt.Errorf("expected a comparison operator, got %s", p.tok)
+	}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	rightTok := p.tok
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	n := cmpNode{left: left, op: op, right: right}
+
+	if op == "=~" || op == "!~" {
+		if rightTok.kind != tokString {
+			p.tok = rightTok
+			return nil, fmt.Errorf("%s expects a regular expression string, got %s", op, rightTok)
+		}
+
+		re, err := regexp.Compile(rightTok.text)
+		if err != nil {
+			p.tok = rightTok
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		n.re = re
+	}
+
+	return n, nil
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	tok := p.tok
+
+	var o operand
+	switch tok.kind {
+	case tokString:
+		o.value = tok.text
+
+	case tokNumber:
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return operand{}, fmt.Errorf("invalid number %q", tok.text)
+		}
+		o.value = f
+
+	case tokDuration:
+		d, err := time.ParseDuration(tok.text)
+		if err != nil {
+			return operand{}, fmt.Errorf("invalid duration %q", tok.text)
+		}
+		o.value = d
+
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			o.value = true
+		case "false":
+			o.value = false
+		case "null":
+			o.value = nil
+		default:
+			o.field = tok.text
+		}
+
+	default:
+		return operand{}, fmt.Errorf("expected a field or a value, got %s", tok)
+	}
+
+	return o, p.advance()
+}
+
+// =============================================================================
+
+// node is a boolean expression of a query.
+type node interface {
+	eval(m map[string]any) bool
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(m map[string]any) bool { return n.left.eval(m) || n.right.eval(m) }
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(m map[string]any) bool { return n.left.eval(m) && n.right.eval(m) }
+
+type notNode struct{ n node }
+
+func (n notNode) eval(m map[string]any) bool { return !n.n.eval(m) }
+
+type existsNode struct{ field string }
+
+func (n existsNode) eval(m map[string]any) bool {
+	_, ok := lookup(m, n.field)
+	return ok
+}
+
+// operand is either a field of the log or a value of the query.
+type operand struct {
+	field string
+	value any
+}
+
+// resolve returns the value of the operand for the log.
+func (o operand) resolve(m map[string]any) (any, bool) {
+	if o.field == "" {
+		return o.value, true
+	}
+	return lookup(m, o.field)
+}
+
+// cmpNode compares two operands, re is the compiled regular
+// expression of =~ and !~.
+type cmpNode struct {
+	left  operand
+	op    string
+	right operand
+	re    *regexp.Regexp
+}
+
+func (n cmpNode) eval(m map[string]any) bool {
+	a, aok := n.left.resolve(m)
+	b, bok := n.right.resolve(m)
+	if !aok || !bok {
+		return n.op == "!=" || n.op == "!~"
+	}
+
+	// Level names only compare by severity against the level field, so
+	// msg == "INFO" doesn't match a message written as info.
+	severity := n.left.field == "level" || n.right.field == "level"
+
+	switch n.op {
+	case "=~":
+		return n.re.MatchString(fmt.Sprint(a))
+	case "!~":
+		return !n.re.MatchString(fmt.Sprint(a))
+	case "==", "!=":
+		eq, ok := equal(a, b, severity)
+		if !ok {
+			return n.op == "!="
+		}
+		return eq == (n.op == "==")
+	}
+
+	c, ok := compare(a, b, severity)
+	if !ok {
+		return false
+	}
+
+	switch n.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+
+	return false
+}
+
+// lookup returns a field of the log, a key holding dots is tried
+// first then the dots walk the nested objects and arrays.
+func lookup(m map[string]any, field string) (any, bool) {
+	if v, ok := m[field]; ok {
+		return v, true
+	}
+
+	var cur any = m
+	for _, part := range strings.Split(field, ".") {
+		switch v := cur.(type) {
+		case map[string]any:
+			next, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			cur = v[i]
+
+		default:
+			return nil, false
+		}
+	}
+
+	return cur, true
+}
+
+// equal reports if a and b are equal, booleans and null are only equal
+// to themselves. false is returned when they can't be compared.
+func equal(a, b any, severity bool) (bool, bool) {
+	switch a.(type) {
+	case bool, nil:
+		return a == b, true
+	}
+	switch b.(type) {
+	case bool, nil:
+		return a == b, true
+	}
+
+	c, ok := compare(a, b, severity)
+	return c == 0, ok
+}
+
+// compare returns -1, 0 or 1 as a is lower, equal or greater than b. The
+// type of the comparison is decided by the values, severity compares level
+// names by severity. false is returned when they can't be ordered, like
+// booleans and null.
+func compare(a, b any, severity bool) (int, bool) {
+	if da, ok := a.(time.Duration); ok {
+		db, ok := toDuration(b)
+		return cmp(da, db), ok
+	}
+	if db, ok := b.(time.Duration); ok {
+		da, ok := toDuration(a)
+		return cmp(da, db), ok
+	}
+
+	switch b.(type) {
+	case bool, nil:
+		return 0, false
+	}
+
+	// The numbers of the logs are compared as the numbers of the query.
+	if n, ok := a.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			a = f
+		}
+	}
+	if n, ok := b.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			b = f
+		}
+	}
+
+	switch av := a.(type) {
+	case float64:
+		bv, ok := toNumber(b)
+		return cmp(av, bv), ok
+	case bool, nil:
+		return 0, false
+	}
+
+	if _, ok := b.(float64); ok {
+		c, ok := compare(b, a, severity)
+		return -c, ok
+	}
+
+	as, bs := fmt.Sprint(a), fmt.Sprint(b)
+
+	// Level names compare by severity, so level >= "warn" works.
+	if severity {
+		la, aok := levels[strings.ToLower(as)]
+		lb, bok := levels[strings.ToLower(bs)]
+		if aok && bok {
+			return cmp(la, lb), true
+		}
+	}
+
+	if ta, err := parseTime(as); err == nil {
+		if tb, err := parseTime(bs); err == nil {
+			return cmp(ta.UnixNano(), tb.UnixNano()), true
+		}
+	}
+
+	return strings.Compare(as, bs), true
+}
+
+// toDuration converts a value to a duration the way logDuration reads
+// the duration field.
+func toDuration(v any) (time.Duration, bool) {
+	return logDuration(map[string]any{"duration": v})
+}
+
+// toNumber converts a field to a number, numbers written as strings
+// are accepted.
+func toNumber(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+// cmp compares two ordered values.
+func cmp[T int | int64 | float64 | time.Duration](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testLog is the log the queries are evaluated against.
+const testLog = `{
+	"level": "warn",
+	"msg": "INFO",
+	"duration": 0.75,
+	"took": "1.5ms",
+	"status": 404,
+	"path": "/v1/users/42",
+	"ok": false,
+	"parent": null,
+	"http": {"method": "GET", "headers": {"host": "localhost"}},
+	"errors": ["timeout", {"code": "E42"}],
+	"k8s.pod": "api-0"
+}`
+
+func TestQueryMatch(t *testing.T) {
+	m, err := decodeLog(testLog)
+	if err != nil {
+		t.Fatalf("decoding the log: %s", err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		match bool
+	}{
+		{"and binds tighter than or", `status == 200 && level == "error" || path =~ "^/v1"`, true},
+		{"or in parentheses", `status == 200 && (level == "error" || path =~ "^/v1")`, false},
+		{"not binds tighter than and", `!exists(user) && status == 200`, false},
+		{"double not", `!!(status == 404)`, true},
+
+		{"nested object", `http.method == "GET"`, true},
+		{"deeply nested object", `http.headers.host == "localhost"`, true},
+		{"array element", `errors.0 == "timeout"`, true},
+		{"object in array", `errors.1.code == "E42"`, true},
+		{"array out of range", `errors.2 == "timeout"`, false},
+		{"key holding dots", `k8s.pod == "api-0"`, true},
+		{"missing field", `user == "bob"`, false},
+		{"missing field not equal", `user != "bob"`, true},
+
+		{"duration against seconds", `duration > 500ms`, true},
+		{"duration against seconds equal", `duration == 750ms`, true},
+		{"duration against seconds lower", `duration < 500ms`, false},
+		{"duration against a string", `took < 2ms`, true},
+		{"duration on the left", `1s > duration`, true},
+
+		{"regex", `path =~ "^/v1/users/[0-9]+$"`, true},
+		{"regex no match", `path =~ "^/v2"`, false},
+		{"negated regex", `path !~ "^/v2"`, true},
+		{"regex on a number", `status =~ "^4"`, true},
+
+		{"exists", `exists(http.method)`, true},
+		{"exists array element", `exists(errors.1)`, true},
+		{"exists missing", `exists(user)`, false},
+		{"exists null", `exists(parent)`, true},
+
+		{"level by severity", `level >= "info"`, true},
+		{"level by severity upper", `level < "ERROR"`, true},
+		{"level equal ignores case", `level == "WARN"`, true},
+		{"severity only for level", `msg == "info"`, false},
+		{"severity only for level exact", `msg == "INFO"`, true},
+
+		{"bool equal", `ok == false`, true},
+		{"bool not equal", `ok != true`, true},
+		{"bool not ordered", `ok > true`, false},
+		{"bool not ordered equal", `ok >= false`, false},
+		{"null equal", `parent == null`, true},
+		{"null not equal", `path != null`, true},
+		{"null not ordered", `path > null`, false},
+		{"null not ordered equal", `parent <= null`, false},
+
+		{"number", `status >= 400 && status < 500`, true},
+		{"string", `http.method < "POST"`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := compileQuery(tt.query)
+			if err != nil {
+				t.Fatalf("compiling %s: %s", tt.query, err)
+			}
+
+			if got := q.match(m); got != tt.match {
+				t.Errorf("%s: expected %t, got %t", tt.query, tt.match, got)
+			}
+		})
+	}
+}
+
+func TestQueryParseErrors(t *testing.T) {
+	tests := []struct {
+		query  string
+		column int
+		msg    string
+	}{
+		{`status ==`, 10, "expected a field or a value"},
+		{`status 200`, 8, "expected a comparison operator"},
+		{`(status == 200`, 15, `expected ")"`},
+		{`status == 200 &&`, 17, "expected a field or a value"},
+		{`path =~ "["`, 9, "invalid regular expression"},
+		{`path =~ 42`, 9, "expects a regular expression string"},
+		{`msg == "open`, 8, "unterminated string"},
+		{`status == 200 ; ok`, 15, "unexpected character"},
+		{`status == 200 ok`, 15, "unexpected"},
+		{`exists(200)`, 8, "exists expects a field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := compileQuery(tt.query)
+			if err == nil {
+				t.Fatalf("%s: expected an error", tt.query)
+			}
+
+			want := fmt.Sprintf("column %d: ", tt.column)
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: expected the error at %s got %s", tt.query, want, err)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("%s: expected the error %q, got %s", tt.query, tt.msg, err)
+			}
+
+			// The caret points at the column of the error.
+			lines := strings.Split(err.Error(), "\n")
+			caret := lines[len(lines)-1]
+			if strings.Index(caret, "^")-4 != tt.column-1 {
+				t.Errorf("%s: caret at the wrong column:\n%s", tt.query, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// histogramWidth is the width of the longest bar of the per-minute histogram.
+const histogramWidth = 50
+
+// maxDurationSamples is the size of the reservoir the duration percentiles
+// are computed from, so following a stream doesn't grow the memory forever.
+const maxDurationSamples = 10000
+
+// maxMinutes is the number of minutes kept by the per-minute histogram,
+// the oldest minute is dropped when a new one comes in.
+const maxMinutes = 24 * 60
+
+// normalizers replace the variable parts of the error messages, so the
+// same error with different ids is counted once.
+var normalizers = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<uuid>"},
+	{regexp.MustCompile(`"[^"]*"`), `"<str>"`},
+	{regexp.MustCompile(`'[^']*'`), `'<str>'`},
+	{regexp.MustCompile(`\b0x[0-9a-fA-F]+\b`), "<hex>"},
+	{regexp.MustCompile(`\b\d+(\.\d+)?(ns|us|µs|ms|s|m|h)?\b`), "<n>"},
+}
+
+// stats aggregates a stream of logs. It's safe for concurrent use so
+// it can be printed periodically while the logs are read.
+type stats struct {
+	mu sync.Mutex
+
+	total    int
+	raw      int
+	levels   map[string]int
+	services map[string]int
+	callers  map[string]int
+	errors   map[string]int
+	minutes  map[time.Time]int
+	top      int
+
+	// durations is a uniform sample of the durations seen, count and max
+	// are exact.
+	durations   []time.Duration
+	durationN   int
+	durationMax time.Duration
+	rng         *rand.Rand
+
+	// droppedMinutes reports if the histogram lost its oldest minutes.
+	droppedMinutes bool
+}
+
+func newStats(top int) *stats {
+	return &stats{
+		levels:   make(map[string]int),
+		services: make(map[string]int),
+		callers:  make(map[string]int),
+		errors:   make(map[string]int),
+		minutes:  make(map[time.Time]int),
+		top:      top,
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// addRaw counts a line that isn't a JSON log.
+func (s *stats) addRaw() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.raw++
+}
+
+// add aggregates a single log.
+func (s *stats) add(m map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.total++
+
+	lvl := cell(m, "level")
+	s.levels[lvl]++
+	s.services[cell(m, "service")]++
+
+	if caller := cell(m, "caller"); caller != "" {
+		s.callers[caller]++
+	}
+
+	if l, ok := levels[strings.ToLower(lvl)]; ok && l >= levels["error"] {
+		msg := cell(m, "msg")
+		if e := cell(m, "error"); e != "" {
+			msg += ": " + e
+		}
+		s.errors[normalize(msg)]++
+	}
+
+	if d, ok := logDuration(m); ok {
+		s.addDuration(d)
+	}
+
+	if ts, ok := logTime(m); ok {
+		s.addMinute(ts.Truncate(time.Minute))
+	}
+}
+
+// addDuration samples a duration by reservoir sampling, every duration
+// seen has the same chance to be kept in the reservoir.
+func (s *stats) addDuration(d time.Duration) {
+	s.durationN++
+	if d > s.durationMax {
+		s.durationMax = d
+	}
+
+	if len(s.durations) < maxDurationSamples {
+		s.durations = append(s.durations, d)
+		return
+	}
+
+	if i := s.rng.Intn(s.durationN); i < maxDurationSamples {
+		s.durations[i] = d
+	}
+}
+
+// addMinute counts a log in its minute, the oldest minute is dropped
+// past maxMinutes.
+func (s *stats) addMinute(minute time.Time) {
+	s.minutes[minute]++
+	if len(s.minutes) <= maxMinutes {
+		return
+	}
+
+	var oldest time.Time
+	for m := range s.minutes {
+		if oldest.IsZero() || m.Before(oldest) {
+			oldest = m
+		}
+	}
+
+	delete(s.minutes, oldest)
+	s.droppedMinutes = true
+}
+
+// print writes the summary as tables.
+func (s *stats) print(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "LOGS\t%d\n", s.total)
+	fmt.Fprintf(tw, "NOT JSON\t%d\n", s.raw)
+
+	s.printCounts(tw, "LEVEL", s.levels, 0)
+	s.printCounts(tw, "SERVICE", s.services, 0)
+	s.printCounts(tw, "CALLER", s.callers, s.top)
+	s.printCounts(tw, "ERROR", s.errors, s.top)
+	s.printDurations(tw)
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	return s.printHistogram(w)
+}
+
+// printCounts writes a count table sorted by count, limited to
+// the top entries when top is positive.
+func (s *stats) printCounts(w io.Writer, title string, counts map[string]int, top int) {
+	if len(counts) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	if top > 0 && len(keys) > top {
+		keys = keys[:top]
+	}
+
+	fmt.Fprintf(w, "\n%s\tCOUNT\n", title)
+	for _, k := range keys {
+		name := k
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(w, "%s\t%d\n", name, counts[k])
+	}
+}
+
+// printDurations writes the percentiles of the duration field, they are
+// estimated from the sample once there are more than maxDurationSamples.
+func (s *stats) printDurations(w io.Writer) {
+	if len(s.durations) == 0 {
+		return
+	}
+
+	d := make([]time.Duration, len(s.durations))
+	copy(d, s.durations)
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+
+	fmt.Fprintf(w, "\nDURATION\tREQUESTS\tP50\tP90\tP95\tP99\tMAX\n")
+	fmt.Fprintf(w, "\t%d\t%s\t%s\t%s\t%s\t%s\n",
+		s.durationN,
+		percentile(d, 50),
+		percentile(d, 90),
+		percentile(d, 95),
+		percentile(d, 99),
+		s.durationMax,
+	)
+}
+
+// printHistogram writes the number of logs per minute as bars.
+func (s *stats) printHistogram(w io.Writer) error {
+	if len(s.minutes) == 0 {
+		return nil
+	}
+
+	minutes := make([]time.Time, 0, len(s.minutes))
+	max := 0
+	for m, c := range s.minutes {
+		minutes = append(minutes, m)
+		if c > max {
+			max = c
+		}
+	}
+	sort.Slice(minutes, func(i, j int) bool { return minutes[i].Before(minutes[j]) })
+
+	title := "PER MINUTE"
+	if s.droppedMinutes {
+		title = fmt.Sprintf("PER MINUTE (last %d minutes)", maxMinutes)
+	}
+
+	if _, err := fmt.Fprintf(w, "\n%s\n", title); err != nil {
+		return err
+	}
+
+	for _, m := range minutes {
+		c := s.minutes[m]
+		bar := c * histogramWidth / max
+		if bar == 0 {
+			bar = 1
+		}
+
+		if _, err := fmt.Fprintf(w, "%s  %-*s  %d\n", m.Format("2006-01-02T15:04Z07:00"), histogramWidth, strings.Repeat("#", bar), c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// percentile returns the p percentile of sorted durations.
+func percentile(d []time.Duration, p int) time.Duration {
+	i := (len(d)*p+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+
+	return d[i]
+}
+
+// normalize replaces the variable parts of a message.
+func normalize(msg string) string {
+	for _, n := range normalizers {
+		msg = n.re.ReplaceAllString(msg, n.repl)
+	}
+
+	return msg
+}
+
+// logDuration reads the duration field of a log, zap writes durations
+// as seconds by default, strings like 1.5ms are accepted too.
+func logDuration(m map[string]any) (time.Duration, bool) {
+	switch v := m["duration"].(type) {
+	case float64:
+		return time.Duration(v * float64(time.Second)), true
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(f * float64(time.Second)), true
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, false
+		}
+		return d, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// traceTemplate is the layout of the logs of a trace timeline, the
+// timestamp and the trace id are already given by the timeline.
+const traceTemplate = "{service}: {level}: {caller}: {msg}"
+
+// traceLog is a log of a trace with its timestamp.
+type traceLog struct {
+	m  map[string]any
+	ts time.Time
+}
+
+// traceGroup is all the logs of a single trace id.
+type traceGroup struct {
+	id       string
+	logs     []traceLog
+	services []string
+	hasError bool
+	first    time.Time
+	last     time.Time
+}
+
+// traces buffers the logs grouped by trace id.
+type traces struct {
+	groups   map[string]*traceGroup
+	untraced int
+}
+
+func newTraces() *traces {
+	return &traces{
+		groups: make(map[string]*traceGroup),
+	}
+}
+
+// add buffers a log in the group of its trace id, the logs
+// without a trace id are only counted.
+func (t *traces) add(m map[string]any) {
+	id := cell(m, traceIDKey)
+	if id == "" || id == emptyTraceID {
+		t.untraced++
+		return
+	}
+
+	g, ok := t.groups[id]
+	if !ok {
+		g = &traceGroup{id: id}
+		t.groups[id] = g
+	}
+
+	ts, _ := logTime(m)
+	g.logs = append(g.logs, traceLog{m: m, ts: ts})
+
+	if g.first.IsZero() || (!ts.IsZero() && ts.Before(g.first)) {
+		g.first = ts
+	}
+	if ts.After(g.last) {
+		g.last = ts
+	}
+
+	if lvl, ok := levels[strings.ToLower(cell(m, "level"))]; ok && lvl >= levels["error"] {
+		g.hasError = true
+	}
+
+	if svc := cell(m, "service"); svc != "" && !contains(g.services, svc) {
+		g.services = append(g.services, svc)
+	}
+}
+
+// print writes every trace as a timeline, the traces are ordered by
+// their first log and the times are relative to it.
+func (t *traces) print(w io.Writer, fm *formatter) error {
+	groups := make([]*traceGroup, 0, len(t.groups))
+	for _, g := range t.groups {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if !groups[i].first.Equal(groups[j].first) {
+			return groups[i].first.Before(groups[j].first)
+		}
+		return groups[i].id < groups[j].id
+	})
+
+	for _, g := range groups {
+		sort.SliceStable(g.logs, func(i, j int) bool { return g.logs[i].ts.Before(g.logs[j].ts) })
+
+		flag := ""
+		if g.hasError {
+			flag = "  ERROR"
+			if fm.color {
+				flag = "  " + levelColors["error"] + "ERROR" + colorReset
+			}
+		}
+
+		if _, err := fmt.Fprintf(w, "trace %s  %s  %d logs  %s elapsed  %s%s\n",
+			g.id,
+			g.first.Format(tsLayouts[0]),
+			len(g.logs),
+			g.last.Sub(g.first),
+			strings.Join(g.services, ","),
+			flag,
+		); err != nil {
+			return err
+		}
+
+		for _, l := range g.logs {
+			rel := "+?"
+			if !l.ts.IsZero() {
+				rel = "+" + l.ts.Sub(g.first).String()
+			}
+
+			// The timeline already shows the trace id and the time.
+			delete(l.m, traceIDKey)
+			delete(l.m, "ts")
+
+			line := strings.ReplaceAll(fm.format(l.m), "\n", "\n"+strings.Repeat(" ", 16))
+			if _, err := fmt.Fprintf(w, "    %-12s%s\n", rel, line); err != nil {
+				return err
+			}
+		}
+
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+
+	if t.untraced > 0 {
+		if _, err := fmt.Fprintf(w, "%d logs without a trace id\n", t.untraced); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+//Package awstest provide a local fake of the aws secret manager service
+//so the aws clients of the project can be exercised offline.
+//
+//	srv := awstest.NewServer()
+//	defer srv.Close()
+//
+//	srv.AddSecret("dbpassword", "postgres", map[string]string{"service": "tgs-api", "build": "dev"})
+//
+//	sess, err := srv.Session(ctx)
+//	store := ssm.New(sess, time.Second)
+package awstest
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+
+	awssession "github.com/aws/aws-sdk-go/aws/session"
+
+	"github.com/Mahamadou828/tgs_with_golang/business/sys/aws/session"
+)
+
+//Region is the region reported by the fake server
+const Region = "eu-west-1"
+
+//Server is an httptest server speaking the secret manager json protocol,
+//the secrets are stored in memory.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	secrets map[string]*secret
+	order   []string
+	version int
+}
+
+//NewServer starts a new fake secret manager server, the caller
+//must call Close when finished.
+func NewServer() *Server {
+	s := Server{
+		secrets: make(map[string]*secret),
+	}
+
+	s.Server = httptest.NewServer(&s)
+
+	return &s
+}
+
+//Session creates an aws session pointed at the fake server
+//using static credentials, the retries are disabled so a failure is reported at once.
+func (s *Server) Session(ctx context.Context) (*awssession.Session, error) {
+	return session.New(ctx, session.Config{
+		Region:          Region,
+		Endpoint:        s.URL,
+		AccessKeyID:     "awstest",
+		SecretAccessKey: "awstest",
+		MaxRetries:      -1,
+	})
+}
+
+//AddSecret stores a secret inside the fake server without going through
+//the aws api, usefull to seed the server before a test.
+func (s *Server) AddSecret(name string, value string, tags map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var t []tag
+	for k, v := range tags {
+		t = append(t, tag{Key: k, Value: v})
+	}
+
+	s.create(name, "", value, t)
+}
+
+//Secrets returns a copy of the current value of all the stored secrets
+func (s *Server) Secrets() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets := make(map[string]string, len(s.secrets))
+	for name, sec := range s.secrets {
+		secrets[name] = sec.Value
+	}
+
+	return secrets
+}
@@ -0,0 +1,440 @@
+package awstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//The error codes returned by the secret manager service
+const (
+	errResourceNotFound = "ResourceNotFoundException"
+	errResourceExists   = "ResourceExistsException"
+	errInvalidParameter = "InvalidParameterException"
+	errInvalidRequest   = "InvalidRequestException"
+)
+
+//defaultMaxResults is the page size of ListSecrets when MaxResults isn't given
+const defaultMaxResults = 100
+
+type tag struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+type secret struct {
+	ARN         string
+	Name        string
+	Description string
+	Value       string
+	VersionID   string
+	Tags        []tag
+	CreatedDate time.Time
+}
+
+//apiError is the json body returned by aws when a call fail
+type apiError struct {
+	status  int
+	Code    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+//ServeHTTP dispatch the call to the operation named in the X-Amz-Target header
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respond(w, nil, &apiError{status: http.StatusMethodNotAllowed, Code: errInvalidRequest, Message: "only POST is supported"})
+		return
+	}
+
+	operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "secretsmanager.")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		out interface{}
+		err error
+	)
+
+	switch operation {
+	case "ListSecrets":
+		var in listSecretsInput
+		if err = decode(r, &in); err == nil {
+			out, err = s.listSecrets(in)
+		}
+	case "GetSecretValue":
+		var in secretIDInput
+		if err = decode(r, &in); err == nil {
+			out, err = s.getSecretValue(in)
+		}
+	case "CreateSecret":
+		var in createSecretInput
+		if err = decode(r, &in); err == nil {
+			out, err = s.createSecret(in)
+		}
+	case "PutSecretValue":
+		var in putSecretValueInput
+		if err = decode(r, &in); err == nil {
+			out, err = s.putSecretValue(in)
+		}
+	case "DeleteSecret":
+		var in secretIDInput
+		if err = decode(r, &in); err == nil {
+			out, err = s.deleteSecret(in)
+		}
+	default:
+		err = &apiError{status: http.StatusBadRequest, Code: "UnknownOperationException", Message: fmt.Sprintf("unknown operation %q", operation)}
+	}
+
+	s.respond(w, out, err)
+}
+
+//decode reads the json body of the request
+func decode(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return &apiError{status: http.StatusBadRequest, Code: "SerializationException", Message: err.Error()}
+	}
+
+	return nil
+}
+
+//respond writes the output of an operation or the error in the aws json format
+func (s *Server) respond(w http.ResponseWriter, out interface{}, err error) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.Header().Set("X-Amzn-RequestId", strconv.FormatInt(time.Now().UnixNano(), 10))
+
+	if err != nil {
+		aerr, ok := err.(*apiError)
+		if !ok {
+			aerr = &apiError{status: http.StatusInternalServerError, Code: "InternalServiceError", Message: err.Error()}
+		}
+
+		w.WriteHeader(aerr.status)
+		json.NewEncoder(w).Encode(aerr)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(out)
+}
+
+//create stores a new secret, the caller must hold the lock
+func (s *Server) create(name string, desc string, value string, tags []tag) *secret {
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+
+	sec := secret{
+		ARN:         fmt.Sprintf("arn:aws:secretsmanager:%s:000000000000:secret:%s", Region, name),
+		Name:        name,
+		Description: desc,
+		Value:       value,
+		VersionID:   s.nextVersion(),
+		Tags:        tags,
+		CreatedDate: time.Now(),
+	}
+
+	if _, ok := s.secrets[name]; !ok {
+		s.order = append(s.order, name)
+	}
+	s.secrets[name] = &sec
+
+	return &sec
+}
+
+//nextVersion returns a new version id, the caller must hold the lock
+func (s *Server) nextVersion() string {
+	s.version++
+	return fmt.Sprintf("00000000-0000-0000-0000-%012d", s.version)
+}
+
+//lookup finds a secret by name or arn, the caller must hold the lock
+func (s *Server) lookup(id string) (*secret, error) {
+	if id == "" {
+		return nil, &apiError{status: http.StatusBadRequest, Code: errInvalidParameter, Message: "SecretId is required"}
+	}
+
+	if sec, ok := s.secrets[id]; ok {
+		return sec, nil
+	}
+
+	for _, sec := range s.secrets {
+		if sec.ARN == id {
+			return sec, nil
+		}
+	}
+
+	return nil, &apiError{status: http.StatusBadRequest, Code: errResourceNotFound, Message: fmt.Sprintf("Secrets Manager can't find the specified secret: %s", id)}
+}
+
+// =============================================================================
+
+type filter struct {
+	Key    string   `json:"Key"`
+	Values []string `json:"Values"`
+}
+
+type listSecretsInput struct {
+	Filters    []filter `json:"Filters"`
+	MaxResults int      `json:"MaxResults"`
+	NextToken  string   `json:"NextToken"`
+}
+
+type secretListEntry struct {
+	ARN         string  `json:"ARN"`
+	Name        string  `json:"Name"`
+	Description string  `json:"Description,omitempty"`
+	Tags        []tag   `json:"Tags,omitempty"`
+	CreatedDate float64 `json:"CreatedDate"`
+}
+
+type listSecretsOutput struct {
+	SecretList []secretListEntry `json:"SecretList"`
+	NextToken  string            `json:"NextToken,omitempty"`
+}
+
+//listSecrets returns a page of the secrets matching all the filters.
+//The NextToken is the index of the first secret of the next page.
+func (s *Server) listSecrets(in listSecretsInput) (listSecretsOutput, error) {
+	maxResults := in.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultMaxResults
+	}
+
+	start := 0
+	if in.NextToken != "" {
+		n, err := strconv.Atoi(in.NextToken)
+		if err != nil || n < 0 {
+			return listSecretsOutput{}, &apiError{status: http.StatusBadRequest, Code: errInvalidParameter, Message: "invalid NextToken"}
+		}
+		start = n
+	}
+
+	var matches []*secret
+	for _, name := range s.order {
+		sec, ok := s.secrets[name]
+		if !ok {
+			continue
+		}
+
+		if matchFilters(sec, in.Filters) {
+			matches = append(matches, sec)
+		}
+	}
+
+	out := listSecretsOutput{SecretList: []secretListEntry{}}
+
+	for i := start; i < len(matches) && i < start+maxResults; i++ {
+		sec := matches[i]
+		out.SecretList = append(out.SecretList, secretListEntry{
+			ARN:         sec.ARN,
+			Name:        sec.Name,
+			Description: sec.Description,
+			Tags:        sec.Tags,
+			CreatedDate: epoch(sec.CreatedDate),
+		})
+	}
+
+	if start+maxResults < len(matches) {
+		out.NextToken = strconv.Itoa(start + maxResults)
+	}
+
+	return out, nil
+}
+
+//matchFilters reports if the secret match every filter, like aws the values
+//of a single filter are prefixes combined with a OR and a value starting
+//with a ! negates the match.
+func matchFilters(sec *secret, filters []filter) bool {
+	for _, f := range filters {
+		var candidates []string
+
+		switch f.Key {
+		case "name":
+			candidates = []string{sec.Name}
+		case "description":
+			candidates = []string{sec.Description}
+		case "tag-key":
+			for _, t := range sec.Tags {
+				candidates = append(candidates, t.Key)
+			}
+		case "tag-value":
+			for _, t := range sec.Tags {
+				candidates = append(candidates, t.Value)
+			}
+		case "all":
+			candidates = append(candidates, sec.Name, sec.Description)
+			for _, t := range sec.Tags {
+				candidates = append(candidates, t.Key, t.Value)
+			}
+		}
+
+		if !matchValues(candidates, f.Values) {
+			return false
+		}
+	}
+
+	return true
+}
+
+//matchValues reports if one of the candidates match one of the values
+func matchValues(candidates []string, values []string) bool {
+	for _, v := range values {
+		negate := strings.HasPrefix(v, "!")
+		v = strings.TrimPrefix(v, "!")
+
+		found := false
+		for _, c := range candidates {
+			if strings.HasPrefix(c, v) {
+				found = true
+				break
+			}
+		}
+
+		if found != negate {
+			return true
+		}
+	}
+
+	return false
+}
+
+// =============================================================================
+
+type secretIDInput struct {
+	SecretID                   string `json:"SecretId"`
+	ForceDeleteWithoutRecovery bool   `json:"ForceDeleteWithoutRecovery"`
+}
+
+type getSecretValueOutput struct {
+	ARN           string   `json:"ARN"`
+	Name          string   `json:"Name"`
+	SecretString  string   `json:"SecretString"`
+	VersionID     string   `json:"VersionId"`
+	VersionStages []string `json:"VersionStages"`
+	CreatedDate   float64  `json:"CreatedDate"`
+}
+
+func (s *Server) getSecretValue(in secretIDInput) (getSecretValueOutput, error) {
+	sec, err := s.lookup(in.SecretID)
+	if err != nil {
+		return getSecretValueOutput{}, err
+	}
+
+	out := getSecretValueOutput{
+		ARN:           sec.ARN,
+		Name:          sec.Name,
+		SecretString:  sec.Value,
+		VersionID:     sec.VersionID,
+		VersionStages: []string{"AWSCURRENT"},
+		CreatedDate:   epoch(sec.CreatedDate),
+	}
+
+	return out, nil
+}
+
+// =============================================================================
+
+type createSecretInput struct {
+	Name         string `json:"Name"`
+	Description  string `json:"Description"`
+	SecretString string `json:"SecretString"`
+	Tags         []tag  `json:"Tags"`
+}
+
+type secretVersionOutput struct {
+	ARN           string   `json:"ARN"`
+	Name          string   `json:"Name"`
+	VersionID     string   `json:"VersionId"`
+	VersionStages []string `json:"VersionStages,omitempty"`
+}
+
+func (s *Server) createSecret(in createSecretInput) (secretVersionOutput, error) {
+	if in.Name == "" {
+		return secretVersionOutput{}, &apiError{status: http.StatusBadRequest, Code: errInvalidParameter, Message: "Name is required"}
+	}
+
+	if _, ok := s.secrets[in.Name]; ok {
+		return secretVersionOutput{}, &apiError{status: http.StatusBadRequest, Code: errResourceExists, Message: fmt.Sprintf("the secret %s already exists", in.Name)}
+	}
+
+	sec := s.create(in.Name, in.Description, in.SecretString, in.Tags)
+
+	out := secretVersionOutput{
+		ARN:       sec.ARN,
+		Name:      sec.Name,
+		VersionID: sec.VersionID,
+	}
+
+	return out, nil
+}
+
+// =============================================================================
+
+type putSecretValueInput struct {
+	SecretID     string `json:"SecretId"`
+	SecretString string `json:"SecretString"`
+}
+
+func (s *Server) putSecretValue(in putSecretValueInput) (secretVersionOutput, error) {
+	sec, err := s.lookup(in.SecretID)
+	if err != nil {
+		return secretVersionOutput{}, err
+	}
+
+	sec.Value = in.SecretString
+	sec.VersionID = s.nextVersion()
+
+	out := secretVersionOutput{
+		ARN:           sec.ARN,
+		Name:          sec.Name,
+		VersionID:     sec.VersionID,
+		VersionStages: []string{"AWSCURRENT"},
+	}
+
+	return out, nil
+}
+
+// =============================================================================
+
+type deleteSecretOutput struct {
+	ARN          string  `json:"ARN"`
+	Name         string  `json:"Name"`
+	DeletionDate float64 `json:"DeletionDate"`
+}
+
+//deleteSecret removes the secret right away, the recovery window is ignored
+func (s *Server) deleteSecret(in secretIDInput) (deleteSecretOutput, error) {
+	sec, err := s.lookup(in.SecretID)
+	if err != nil {
+		return deleteSecretOutput{}, err
+	}
+
+	delete(s.secrets, sec.Name)
+
+	for i, name := range s.order {
+		if name == sec.Name {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+
+	out := deleteSecretOutput{
+		ARN:          sec.ARN,
+		Name:         sec.Name,
+		DeletionDate: epoch(time.Now()),
+	}
+
+	return out, nil
+}
+
+//epoch returns the time in the aws json timestamp format
+func epoch(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
@@ -0,0 +1,140 @@
+//Package retry provide a retryer shared by every aws client of the project.
+//Requests are retried with an exponential backoff and a full jitter when aws
+//throttles them or answers with a 5xx status code.
+//For more details, see: https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+package retry
+
+import (
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"go.uber.org/zap"
+)
+
+//Default values used when a field of the Config is left to zero
+const (
+	DefaultMaxRetries     = 5
+	DefaultBaseDelay      = 100 * time.Millisecond
+	DefaultMaxDelay       = 5 * time.Second
+	DefaultMaxElapsedTime = 30 * time.Second
+)
+
+//Config represent the backoff policy of the retryer
+type Config struct {
+	//MaxRetries is the maximum number of retries for a single request
+	MaxRetries int
+	//BaseDelay is the delay used to compute the exponential backoff
+	BaseDelay time.Duration
+	//MaxDelay is the maximum delay between two attempts
+	MaxDelay time.Duration
+	//MaxElapsedTime is the maximum time spent on a request, retries included
+	MaxElapsedTime time.Duration
+}
+
+//Retryer implements the aws request.Retryer interface
+type Retryer struct {
+	log *zap.Logger
+	cfg Config
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+//New creates a new Retryer, zero fields of the config are
+//replaced by their default value.
+func New(log *zap.Logger, cfg Config) *Retryer {
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = DefaultBaseDelay
+	}
+
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = DefaultMaxDelay
+	}
+
+	if cfg.MaxElapsedTime <= 0 {
+		cfg.MaxElapsedTime = DefaultMaxElapsedTime
+	}
+
+	return &Retryer{
+		log: log,
+		cfg: cfg,
+		rnd: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+//MaxRetries returns the maximum number of retries for a single request
+func (rt *Retryer) MaxRetries() int {
+	return rt.cfg.MaxRetries
+}
+
+//ShouldRetry returns true if the request failed because of a throttling
+//or a 5xx error and the max elapsed time is not reached yet.
+func (rt *Retryer) ShouldRetry(r *request.Request) bool {
+	if r.Error == nil {
+		return false
+	}
+
+	if time.Since(r.Time) >= rt.cfg.MaxElapsedTime {
+		return false
+	}
+
+	if r.IsErrorThrottle() {
+		return true
+	}
+
+	if r.HTTPResponse != nil && r.HTTPResponse.StatusCode >= http.StatusInternalServerError && r.HTTPResponse.StatusCode != http.StatusNotImplemented {
+		return true
+	}
+
+	return false
+}
+
+//RetryRules returns the delay to wait before sending the next attempt.
+//The delay is a random value between zero and the exponential backoff,
+//it never exceed the remaining time before the max elapsed time.
+func (rt *Retryer) RetryRules(r *request.Request) time.Duration {
+	backoff := rt.cfg.MaxDelay
+
+	//Stop doubling the delay once the shift would overflow the max delay
+	if r.RetryCount < 32 {
+		if d := rt.cfg.BaseDelay << uint(r.RetryCount); d > 0 && d < backoff {
+			backoff = d
+		}
+	}
+
+	rt.mu.Lock()
+	delay := time.Duration(rt.rnd.Int63n(int64(backoff) + 1))
+	rt.mu.Unlock()
+
+	if remaining := rt.cfg.MaxElapsedTime - time.Since(r.Time); delay > remaining {
+		delay = remaining
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+
+	status := 0
+	if r.HTTPResponse != nil {
+		status = r.HTTPResponse.StatusCode
+	}
+
+	rt.log.Warn("retrying aws request",
+		zap.String("awsService", r.ClientInfo.ServiceName),
+		zap.String("operation", r.Operation.Name),
+		zap.Int("attempt", r.RetryCount+1),
+		zap.Int("maxRetries", rt.cfg.MaxRetries),
+		zap.Int("status", status),
+		zap.Duration("delay", delay),
+		zap.Error(r.Error),
+	)
+
+	return delay
+}
@@ -1,17 +1,136 @@
+//Package session provide a configurable constructor for the aws session
+//shared by every aws client of the project.
+//For more details, see: https://docs.aws.amazon.com/sdk-for-go/api/aws/session/
 package session
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 )
 
-func New() (*session.Session, error) {
-	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String("eu-west-1"),
+//DefaultRegion is the region used when neither the config nor the shared
+//config profile and the environment give one
+const DefaultRegion = "eu-west-1"
+
+var (
+	ErrMissingSecretKey   = errors.New("error: a static access key id was given without a secret access key")
+	ErrMissingAccessKey   = errors.New("error: a static secret access key was given without an access key id")
+	ErrInvalidCredentials = errors.New("error: invalid aws credentials")
+)
+
+//Config represent all the options needed to construct an aws session.
+//Every field is optional, the zero value will construct a session for the
+//region of the environment or the default profile, else the DefaultRegion,
+//using the default aws credentials chain.
+type Config struct {
+	//Region is the aws region to send the request to, it overrides the
+	//region of the profile
+	Region string
+	//Profile is the name of the shared config profile to use
+	Profile string
+	//Endpoint is a custom endpoint url, usefull to target a local aws
+	//stand-in such as LocalStack or moto
+	Endpoint string
+	//AccessKeyID, SecretAccessKey and SessionToken are static credentials,
+	//they override the default credentials chain when set
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	//RoleARN is the arn of a role to assume with sts before sending any request
+	RoleARN string
+	//RoleSessionName is the session name used when assuming the role
+	RoleSessionName string
+	//ExternalID is the external id used when assuming the role
+	ExternalID string
+	//MaxRetries is the maximum number of retries for a request, zero
+	//will use the sdk default and a negative value disables the retries.
+	//It's ignored when a Retryer is given
+	MaxRetries int
+	//Retryer drive the retry behavior of every client using the session,
+	//see the business/sys/aws/retry package
+	Retryer request.Retryer
+	//HTTPTimeout is the timeout of the http client used by the session,
+	//zero means no timeout
+	HTTPTimeout time.Duration
+}
+
+//New creates a new aws session based on the given config and validates
+//that the resolved credentials can be retrieved before the context is done.
+func New(ctx context.Context, cfg Config) (*session.Session, error) {
+	if cfg.AccessKeyID != "" && cfg.SecretAccessKey == "" {
+		return nil, ErrMissingSecretKey
+	}
+
+	if cfg.SecretAccessKey != "" && cfg.AccessKeyID == "" {
+		return nil, ErrMissingAccessKey
+	}
+
+	awsCfg := aws.NewConfig().
+		WithHTTPClient(&http.Client{Timeout: cfg.HTTPTimeout})
+
+	//Without a region the one of the profile or the environment is used
+	if cfg.Region != "" {
+		awsCfg.WithRegion(cfg.Region)
+	}
+
+	if cfg.Endpoint != "" {
+		awsCfg.WithEndpoint(cfg.Endpoint)
+	}
+
+	switch {
+	case cfg.Retryer != nil:
+		//Always ask the retryer, even when the sdk already flagged
+		//the error as retryable
+		request.WithRetryer(awsCfg, cfg.Retryer)
+		awsCfg.EnforceShouldRetryCheck = aws.Bool(true)
+	case cfg.MaxRetries > 0:
+		awsCfg.WithMaxRetries(cfg.MaxRetries)
+	case cfg.MaxRetries < 0:
+		awsCfg.WithMaxRetries(0)
+	}
+
+	if cfg.AccessKeyID != "" {
+		awsCfg.WithCredentials(credentials.NewStaticCredentials(cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken))
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *awsCfg,
+		Profile:           cfg.Profile,
+		SharedConfigState: session.SharedConfigEnable,
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to create aws session: %w", err)
+	}
+
+	region := aws.StringValue(sess.Config.Region)
+	if region == "" {
+		region = DefaultRegion
+		sess.Config.Region = aws.String(region)
+	}
+
+	//Every request sent with the session will use the assumed role credentials
+	if cfg.RoleARN != "" {
+		sess.Config.Credentials = stscreds.NewCredentials(sess, cfg.RoleARN, func(p *stscreds.AssumeRoleProvider) {
+			p.RoleSessionName = cfg.RoleSessionName
+			if cfg.ExternalID != "" {
+				p.ExternalID = aws.String(cfg.ExternalID)
+			}
+		})
+	}
+
+	//Validate the credentials now rather than on the first request
+	if _, err := sess.Config.Credentials.GetWithContext(ctx); err != nil {
+		return nil, fmt.Errorf("%w: region %s, profile %q, role %q: %s", ErrInvalidCredentials, region, cfg.Profile, cfg.RoleARN, err)
 	}
 
 	return sess, nil
@@ -0,0 +1,56 @@
+package session_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+
+	"github.com/Mahamadou828/tgs_with_golang/business/sys/aws/session"
+)
+
+func TestRegion(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "config")
+	profiles := "[profile regional]\nregion = us-east-2\n\n[profile bare]\noutput = json\n"
+	if err := os.WriteFile(cfgFile, []byte(profiles), 0o600); err != nil {
+		t.Fatalf("writing the shared config: %s", err)
+	}
+
+	t.Setenv("AWS_CONFIG_FILE", cfgFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_PROFILE", "")
+
+	tests := []struct {
+		name    string
+		region  string
+		profile string
+		want    string
+	}{
+		{"profile region", "", "regional", "us-east-2"},
+		{"config overrides the profile", "ap-south-1", "regional", "ap-south-1"},
+		{"profile without region", "", "bare", session.DefaultRegion},
+		{"config region", "ca-central-1", "", "ca-central-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sess, err := session.New(context.Background(), session.Config{
+				Region:          tt.region,
+				Profile:         tt.profile,
+				AccessKeyID:     "test",
+				SecretAccessKey: "test",
+			})
+			if err != nil {
+				t.Fatalf("creating the session: %s", err)
+			}
+
+			if got := aws.StringValue(sess.Config.Region); got != tt.want {
+				t.Errorf("expected the region %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
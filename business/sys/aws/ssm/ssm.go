@@ -2,30 +2,43 @@
 //For more details, see: https://docs.aws.amazon.com/sdk-for-go/api/
 package ssm
 
-//@todo pass the session in params
-
 import (
+	"context"
 	"fmt"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 )
 
-//ListSecrets Retrieve all secrets bound to that specific account
-//and filter them based on the service pass and the build.
-func ListSecrets(service string, build string) (map[string]string, error) {
-	//Initiate a new aws session
-	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String("eu-west-1"),
-	})
+//DefaultCallTimeout is the deadline applied to a call when no timeout is given
+const DefaultCallTimeout = 10 * time.Second
 
-	if err != nil {
-		return nil, err
+//SSM manages the secrets hosted inside the aws ssm service
+type SSM struct {
+	svc         *secretsmanager.SecretsManager
+	callTimeout time.Duration
+}
+
+//New creates a new SSM client. The session should be constructed with the
+//business/sys/aws/session package so the requests are retried by the shared retryer.
+//Every call to aws is bound to a deadline of callTimeout.
+func New(sess *session.Session, callTimeout time.Duration) *SSM {
+	if callTimeout <= 0 {
+		callTimeout = DefaultCallTimeout
 	}
 
-	svc := secretsmanager.New(sess)
+	return &SSM{
+		svc:         secretsmanager.New(sess),
+		callTimeout: callTimeout,
+	}
+}
 
+//ListSecrets Retrieve all secrets bound to that specific account
+//and filter them based on the service pass and the build.
+func (s *SSM) ListSecrets(ctx context.Context, service string, build string) (map[string]string, error) {
 	input := &secretsmanager.ListSecretsInput{
 		Filters: []*secretsmanager.Filter{
 			{
@@ -39,59 +52,76 @@ func ListSecrets(service string, build string) (map[string]string, error) {
 		},
 	}
 
-	result, err := svc.ListSecrets(input)
+	var names []*string
 
-	secrets := make(map[string]string)
+	listCtx, cancel := context.WithTimeout(ctx, s.callTimeout)
+	defer cancel()
 
-	for _, value := range result.SecretList {
-		input := &secretsmanager.GetSecretValueInput{
-			SecretId: value.Name,
+	err := s.svc.ListSecretsPagesWithContext(listCtx, input, func(page *secretsmanager.ListSecretsOutput, lastPage bool) bool {
+		for _, value := range page.SecretList {
+			names = append(names, value.Name)
 		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
 
-		result, err := svc.GetSecretValue(input)
+	secrets := make(map[string]string)
 
+	for _, name := range names {
+		value, err := s.getSecretValue(ctx, name)
 		if err != nil {
-			if aerr, ok := err.(awserr.Error); ok {
-				switch aerr.Code() {
-				case secretsmanager.ErrCodeResourceNotFoundException:
-					return nil, fmt.Errorf("failed to retrieve secret: %s, error: %s, %s", *value.Name, secretsmanager.ErrCodeResourceNotFoundException, aerr.Error())
-				case secretsmanager.ErrCodeInvalidParameterException:
-					return nil, fmt.Errorf("failed to retrieve secret: %s, error: %s, %s", *value.Name, secretsmanager.ErrCodeInvalidParameterException, aerr.Error())
-				case secretsmanager.ErrCodeInvalidRequestException:
-					return nil, fmt.Errorf("failed to retrieve secret: %s, error: %s, %s", *value.Name, secretsmanager.ErrCodeInvalidRequestException, aerr.Error())
-				case secretsmanager.ErrCodeDecryptionFailure:
-					return nil, fmt.Errorf("failed to retrieve secret: %s, error: %s, %s", *value.Name, secretsmanager.ErrCodeDecryptionFailure, aerr.Error())
-				case secretsmanager.ErrCodeInternalServiceError:
-					return nil, fmt.Errorf("failed to retrieve secret: %s, error: %s, %s", *value.Name, secretsmanager.ErrCodeInternalServiceError, aerr.Error())
-				default:
-					return nil, fmt.Errorf(aerr.Error())
-				}
-			} else {
-				// Print the error, cast err to awserr.Error to get the Code and
-				// Message from an error.
-				return nil, err
-			}
+			return nil, err
 		}
 
-		secrets[*result.Name] = *result.SecretString
-
+		secrets[*name] = value
 	}
 
 	return secrets, nil
 }
 
-//CreateSecret creates a new secret and host it inside the aws ssm service
-func CreateSecret(name string, value string, service string, build string, desc string) error {
-	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String("eu-west-1"),
-	})
+//getSecretValue retrieve the value of a single secret
+func (s *SSM) getSecretValue(ctx context.Context, name *string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.callTimeout)
+	defer cancel()
+
+	input := &secretsmanager.GetSecretValueInput{
+		SecretId: name,
+	}
+
+	result, err := s.svc.GetSecretValueWithContext(ctx, input)
 
 	if err != nil {
-		//@todo handle the error
-		panic(err)
+		if aerr, ok := err.(awserr.Error); ok {
+			switch aerr.Code() {
+			case secretsmanager.ErrCodeResourceNotFoundException:
+				return "", fmt.Errorf("failed to retrieve secret: %s, error: %s, %s", *name, secretsmanager.ErrCodeResourceNotFoundException, aerr.Error())
+			case secretsmanager.ErrCodeInvalidParameterException:
+				return "", fmt.Errorf("failed to retrieve secret: %s, error: %s, %s", *name, secretsmanager.ErrCodeInvalidParameterException, aerr.Error())
+			case secretsmanager.ErrCodeInvalidRequestException:
+				return "", fmt.Errorf("failed to retrieve secret: %s, error: %s, %s", *name, secretsmanager.ErrCodeInvalidRequestException, aerr.Error())
+			case secretsmanager.ErrCodeDecryptionFailure:
+				return "", fmt.Errorf("failed to retrieve secret: %s, error: %s, %s", *name, secretsmanager.ErrCodeDecryptionFailure, aerr.Error())
+			case secretsmanager.ErrCodeInternalServiceError:
+				return "", fmt.Errorf("failed to retrieve secret: %s, error: %s, %s", *name, secretsmanager.ErrCodeInternalServiceError, aerr.Error())
+			default:
+				return "", fmt.Errorf("failed to retrieve secret: %s, %w", *name, aerr)
+			}
+		} else {
+			// Print the error, cast err to awserr.Error to get the Code and
+			// Message from an error.
+			return "", err
+		}
 	}
 
-	svc := secretsmanager.New(sess)
+	return aws.StringValue(result.SecretString), nil
+}
+
+//CreateSecret creates a new secret and host it inside the aws ssm service
+func (s *SSM) CreateSecret(ctx context.Context, name string, value string, service string, build string, desc string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.callTimeout)
+	defer cancel()
 
 	input := &secretsmanager.CreateSecretInput{
 		Description: aws.String(desc),
@@ -109,7 +139,7 @@ func CreateSecret(name string, value string, service string, build string, desc
 		SecretString: aws.String(value),
 	}
 
-	_, err = svc.CreateSecret(input)
+	_, err := s.svc.CreateSecretWithContext(ctx, input)
 
 	if err != nil {
 		if aerr, ok := err.(awserr.Error); ok {
@@ -146,3 +176,20 @@ func CreateSecret(name string, value string, service string, build string, desc
 
 	return nil
 }
+
+//StatusCheck returns nil if it can successfully talk to the secrets manager
+//service. It returns a non-nil error otherwise.
+func (s *SSM) StatusCheck(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.callTimeout)
+	defer cancel()
+
+	input := &secretsmanager.ListSecretsInput{
+		MaxResults: aws.Int64(1),
+	}
+
+	if _, err := s.svc.ListSecretsWithContext(ctx, input); err != nil {
+		return fmt.Errorf("failed to reach secrets manager: %w", err)
+	}
+
+	return nil
+}
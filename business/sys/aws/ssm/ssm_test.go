@@ -0,0 +1,96 @@
+package ssm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+
+	"github.com/Mahamadou828/tgs_with_golang/business/sys/aws/awstest"
+)
+
+//newStore starts a fake secret manager and returns a client pointed at it
+func newStore(t *testing.T) (*awstest.Server, *SSM) {
+	t.Helper()
+
+	srv := awstest.NewServer()
+	t.Cleanup(srv.Close)
+
+	sess, err := srv.Session(context.Background())
+	if err != nil {
+		t.Fatalf("creating the session: %s", err)
+	}
+
+	return srv, New(sess, time.Second)
+}
+
+func TestListSecrets(t *testing.T) {
+	srv, store := newStore(t)
+
+	//More secrets than a page of ListSecrets so the paging is exercised
+	for i := 0; i < 150; i++ {
+		name := fmt.Sprintf("secret%03d", i)
+		srv.AddSecret(name, "value-"+name, map[string]string{"service": "tgs-api", "build": "dev"})
+	}
+	srv.AddSecret("otherservice", "other", map[string]string{"service": "billing", "build": "prod"})
+
+	secrets, err := store.ListSecrets(context.Background(), "tgs-api", "dev")
+	if err != nil {
+		t.Fatalf("listing the secrets: %s", err)
+	}
+
+	if len(secrets) != 150 {
+		t.Fatalf("expected 150 secrets, got %d", len(secrets))
+	}
+
+	if _, ok := secrets["otherservice"]; ok {
+		t.Errorf("the secret of another service was returned")
+	}
+
+	if v := secrets["secret149"]; v != "value-secret149" {
+		t.Errorf("secret149: expected value-secret149, got %q", v)
+	}
+}
+
+func TestGetSecretValueNotFound(t *testing.T) {
+	_, store := newStore(t)
+
+	_, err := store.getSecretValue(context.Background(), aws.String("missing"))
+	if err == nil {
+		t.Fatal("expected an error for a missing secret")
+	}
+
+	if !strings.Contains(err.Error(), secretsmanager.ErrCodeResourceNotFoundException) {
+		t.Errorf("expected a %s error, got %s", secretsmanager.ErrCodeResourceNotFoundException, err)
+	}
+}
+
+func TestCreateSecret(t *testing.T) {
+	srv, store := newStore(t)
+	ctx := context.Background()
+
+	if err := store.CreateSecret(ctx, "dbpassword", "postgres", "tgs-api", "dev", "database password"); err != nil {
+		t.Fatalf("creating the secret: %s", err)
+	}
+
+	if v := srv.Secrets()["dbpassword"]; v != "postgres" {
+		t.Errorf("expected the stored value postgres, got %q", v)
+	}
+
+	err := store.CreateSecret(ctx, "dbpassword", "other", "tgs-api", "dev", "database password")
+	if err == nil {
+		t.Fatal("expected an error when creating an existing secret")
+	}
+
+	if !strings.Contains(err.Error(), secretsmanager.ErrCodeResourceExistsException) {
+		t.Errorf("expected a %s error, got %s", secretsmanager.ErrCodeResourceExistsException, err)
+	}
+
+	if v := srv.Secrets()["dbpassword"]; v != "postgres" {
+		t.Errorf("the existing secret was overwritten with %q", v)
+	}
+}
@@ -0,0 +1,52 @@
+//Package metrics constructs the metrics the application will track, they
+//are published with expvar on the /debug/vars endpoint of the debug server.
+package metrics
+
+import (
+	"expvar"
+	"runtime"
+)
+
+//This holds the single instance of the metrics value needed for
+//collecting metrics. The expvar package is already based on a singleton
+//for the different metrics that are registered with the package so there
+//isn't much choice here.
+var m *metrics
+
+//metrics represents the set of metrics we gather. These fields are
+//safe to be accessed concurrently thanks to expvar. No extra abstraction is required.
+type metrics struct {
+	requests *expvar.Int
+	errors   *expvar.Int
+	panics   *expvar.Int
+}
+
+//init constructs the metrics value that will be used to capture metrics.
+//The metrics value is stored in a package level variable since everything
+//inside of expvar is registered as a singleton.
+func init() {
+	m = &metrics{
+		requests: expvar.NewInt("requests"),
+		errors:   expvar.NewInt("errors"),
+		panics:   expvar.NewInt("panics"),
+	}
+
+	expvar.Publish("goroutines", expvar.Func(func() any {
+		return runtime.NumGoroutine()
+	}))
+}
+
+//AddRequests increments the number of requests handled.
+func AddRequests() {
+	m.requests.Add(1)
+}
+
+//AddErrors increments the number of requests that failed.
+func AddErrors() {
+	m.errors.Add(1)
+}
+
+//AddPanics increments the number of requests that panicked.
+func AddPanics() {
+	m.panics.Add(1)
+}
@@ -0,0 +1,82 @@
+package mid
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Mahamadou828/tgs_with_golang/business/sys/validate"
+	"github.com/Mahamadou828/tgs_with_golang/foundation/logger"
+	"github.com/Mahamadou828/tgs_with_golang/foundation/web"
+	"go.uber.org/zap"
+)
+
+//Errors handles errors coming out of the call chain. It detects normal
+//application errors which are used to respond to the client in a uniform way.
+//Every error is logged, only the message of a RequestError reaches the client
+//and any other error is answered with a generic 500. A RequestError is an
+//expected answer logged as a warning, any other error is logged as an error.
+//The stack of this middleware says nothing about the error so none is logged,
+//and a recovered panic is only logged by Panics with the stack of the panic.
+func Errors(log *zap.Logger) web.Middleware {
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			//Run the next handler and catch any propagated error.
+			err := handler(ctx, w, r)
+			if err == nil {
+				return nil
+			}
+
+			//Log the error with the trace id, the details never leave the service.
+			log := logger.FromContext(logger.WithContext(ctx, log)).WithOptions(zap.AddStacktrace(zap.FatalLevel))
+			fields := []zap.Field{
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.Error(err),
+			}
+
+			//Build out the error response.
+			var er validate.ErrorResponse
+			var status int
+			switch {
+			case validate.IsRequestError(err):
+				reqErr := validate.GetRequestError(err)
+				er = validate.ErrorResponse{
+					Error: reqErr.Error(),
+				}
+				status = reqErr.Status
+
+				log.Warn("request failed", append(fields, zap.Int("statusCode", status))...)
+
+			default:
+				er = validate.ErrorResponse{
+					Error: http.StatusText(http.StatusInternalServerError),
+				}
+				status = http.StatusInternalServerError
+
+				//A recovered panic was already logged by Panics.
+				var pe *panicError
+				if !errors.As(err, &pe) {
+					log.Error("request failed", fields...)
+				}
+			}
+
+			//Respond with the error back to the client.
+			if err := web.Respond(ctx, w, er, status); err != nil {
+				return err
+			}
+
+			//If we receive the shutdown err we need to return it
+			//back to the base handler to shut down the service.
+			if web.IsShutdown(err) {
+				return err
+			}
+
+			return nil
+		}
+
+		return h
+	}
+
+	return m
+}
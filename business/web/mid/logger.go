@@ -0,0 +1,66 @@
+package mid
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Mahamadou828/tgs_with_golang/foundation/logger"
+	"github.com/Mahamadou828/tgs_with_golang/foundation/web"
+	"go.uber.org/zap"
+)
+
+//Logger writes some information about the request to the logs in the
+//format: method path remoteaddr -> statusCode bytes duration. The requests
+//to the excluded paths, such as the health checks, aren't logged.
+func Logger(log *zap.Logger, exclude ...string) web.Middleware {
+	excluded := make(map[string]bool, len(exclude))
+	for _, path := range exclude {
+		excluded[path] = true
+	}
+
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			//Every log written with the logger of the context carries the
+			//trace id of the request, the excluded requests included.
+			ctx = logger.WithContext(ctx, log)
+			r = r.WithContext(ctx)
+
+			if excluded[r.URL.Path] {
+				return handler(ctx, w, r)
+			}
+
+			log := logger.FromContext(ctx)
+
+			v, err := web.GetValues(ctx)
+			if err != nil {
+				return web.NewShutdownError("web value missing from context")
+			}
+
+			rw := web.NewResponseWriter(w)
+
+			fields := []zap.Field{
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.String("remoteaddr", r.RemoteAddr),
+			}
+
+			log.Info("request started", fields...)
+
+			err = handler(ctx, rw, r)
+
+			log.Info("request completed", append(fields,
+				zap.Int("statusCode", rw.Status()),
+				zap.Int("bytes", rw.Bytes()),
+				zap.Duration("duration", time.Since(v.Now)),
+			)...)
+
+			//Return the error so it can be handled further up the chain.
+			return err
+		}
+
+		return h
+	}
+
+	return m
+}
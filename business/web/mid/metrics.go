@@ -0,0 +1,34 @@
+//Package mid contains the set of middleware functions shared by the
+//web services of the project.
+package mid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Mahamadou828/tgs_with_golang/business/sys/metrics"
+	"github.com/Mahamadou828/tgs_with_golang/foundation/web"
+)
+
+//Metrics updates program counters.
+func Metrics() web.Middleware {
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			//Call the next handler.
+			err := handler(ctx, w, r)
+
+			//Increment the request and errors counters.
+			metrics.AddRequests()
+			if err != nil {
+				metrics.AddErrors()
+			}
+
+			//Return the error so it can be handled further up the chain.
+			return err
+		}
+
+		return h
+	}
+
+	return m
+}
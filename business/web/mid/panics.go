@@ -0,0 +1,71 @@
+package mid
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/Mahamadou828/tgs_with_golang/business/sys/metrics"
+	"github.com/Mahamadou828/tgs_with_golang/foundation/logger"
+	"github.com/Mahamadou828/tgs_with_golang/foundation/web"
+	"go.uber.org/zap"
+)
+
+//panicError is the error of a recovered panic, it's already logged with the
+//stack of the panic so Errors doesn't log it again.
+type panicError struct {
+	rec any
+}
+
+func (pe *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", pe.rec)
+}
+
+//Panics recovers from panics and converts the panic to an error so it is
+//reported in Metrics and handled in Errors, the client gets a generic 500
+//and the panic is logged with its stack trace.
+func Panics(log *zap.Logger) web.Middleware {
+	m := func(handler web.Handler) web.Handler {
+		//Use the named return value so the error can be set by the defer.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) (err error) {
+			//Defer a function to recover from a panic and set the err return
+			//variable after the fact.
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+
+				//The http package uses this panic to abort a response on
+				//purpose, it must reach the server.
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				//The stack of the panic replaces the one zap would capture
+				//here, which would only point at this function.
+				log := logger.FromContext(logger.WithContext(ctx, log))
+				log.WithOptions(zap.AddStacktrace(zap.FatalLevel)).Error("panic recovered",
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.String("panic", fmt.Sprint(rec)),
+					zap.String("stacktrace", string(debug.Stack())),
+				)
+
+				metrics.AddPanics()
+
+				//The message of the panic never reaches the client, Errors
+				//answers the unknown errors with a generic 500.
+				err = &panicError{rec: rec}
+			}()
+
+			//Call the next handler and set its return value in the err variable.
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
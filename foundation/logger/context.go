@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+//Keys of the fields attached to every log line of a request
+const (
+	TraceIDKey = "traceID"
+	SpanIDKey  = "spanID"
+)
+
+//ctxKey represents the type of value for the context key.
+type ctxKey int
+
+//key is how the request logging values are stored/retrieved.
+const key ctxKey = 1
+
+//values represent the logging state of a request
+type values struct {
+	base    *zap.Logger
+	traceID string
+	spanID  string
+	fields  []zap.Field
+
+	//log is the base logger with all the request fields attached,
+	//it's computed once per change instead of once per log line
+	log *zap.Logger
+}
+
+//WithContext returns a copy of the context holding the logger, the logs
+//written with FromContext will carry the request fields of the context.
+func WithContext(ctx context.Context, log *zap.Logger) context.Context {
+	v := getValues(ctx)
+	v.base = log
+	return setValues(ctx, v)
+}
+
+//WithTraceID returns a copy of the context holding the trace and span id
+//of the request.
+func WithTraceID(ctx context.Context, traceID string, spanID string) context.Context {
+	v := getValues(ctx)
+	v.traceID = traceID
+	v.spanID = spanID
+	return setValues(ctx, v)
+}
+
+//WithFields returns a copy of the context holding additional request-scoped fields
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	v := getValues(ctx)
+	v.fields = append(v.fields[:len(v.fields):len(v.fields)], fields...)
+	return setValues(ctx, v)
+}
+
+//FromContext returns the logger stored in the context with the trace id,
+//the span id and the request-scoped fields attached. When no logger
+//was stored the global zap logger is used.
+func FromContext(ctx context.Context) *zap.Logger {
+	v, ok := ctx.Value(key).(*values)
+	if !ok {
+		return zap.L()
+	}
+
+	return v.log
+}
+
+//TraceID returns the trace id stored in the context
+func TraceID(ctx context.Context) string {
+	v, ok := ctx.Value(key).(*values)
+	if !ok {
+		return ""
+	}
+
+	return v.traceID
+}
+
+//getValues returns a copy of the values stored in the context
+func getValues(ctx context.Context) values {
+	v, ok := ctx.Value(key).(*values)
+	if !ok {
+		return values{}
+	}
+
+	return *v
+}
+
+//setValues computes the request logger and stores the values in the context
+func setValues(ctx context.Context, v values) context.Context {
+	log := v.base
+	if log == nil {
+		log = zap.L()
+	}
+
+	fields := make([]zap.Field, 0, len(v.fields)+2)
+	if v.traceID != "" {
+		fields = append(fields, zap.String(TraceIDKey, v.traceID))
+	}
+	if v.spanID != "" {
+		fields = append(fields, zap.String(SpanIDKey, v.spanID))
+	}
+	fields = append(fields, v.fields...)
+
+	v.log = log.With(fields...)
+
+	return context.WithValue(ctx, key, &v)
+}
@@ -0,0 +1,154 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+//LevelController changes the level of a logger at runtime. A change can be
+//given a duration after which the previous level is restored, so a forgotten
+//debug level doesn't flood the logs.
+type LevelController struct {
+	level zap.AtomicLevel
+
+	mu       sync.Mutex
+	base     zapcore.Level
+	timer    *time.Timer
+	revertAt time.Time
+}
+
+//NewLevelController creates a controller for the given level, the level
+//must be shared with the logger using the WithAtomicLevel option.
+func NewLevelController(level zap.AtomicLevel) *LevelController {
+	return &LevelController{
+		level: level,
+	}
+}
+
+//WithAtomicLevel makes the logger use the given level, so it can be
+//changed at runtime with a LevelController.
+func WithAtomicLevel(level zap.AtomicLevel) Option {
+	return func(s *settings) error {
+		level.SetLevel(s.config.Level.Level())
+		s.config.Level = level
+		return nil
+	}
+}
+
+//Level returns the current level
+func (lc *LevelController) Level() zapcore.Level {
+	return lc.level.Level()
+}
+
+//SetLevel changes the level, if revertAfter is positive the level active before
+//the change is restored once the duration is elapsed. A new change cancels
+//the pending revert but keeps the level to restore.
+func (lc *LevelController) SetLevel(level zapcore.Level, revertAfter time.Duration) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if lc.timer == nil {
+		lc.base = lc.level.Level()
+	} else {
+		lc.timer.Stop()
+		lc.timer = nil
+		lc.revertAt = time.Time{}
+	}
+
+	lc.level.SetLevel(level)
+
+	if revertAfter <= 0 {
+		return
+	}
+
+	lc.revertAt = time.Now().Add(revertAfter)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(revertAfter, func() {
+		lc.mu.Lock()
+		defer lc.mu.Unlock()
+
+		//The revert was cancelled by a new change
+		if lc.timer != timer {
+			return
+		}
+
+		lc.level.SetLevel(lc.base)
+		lc.timer = nil
+		lc.revertAt = time.Time{}
+	})
+	lc.timer = timer
+}
+
+//levelPayload is the body of the GET and PUT requests
+type levelPayload struct {
+	Level       string     `json:"level"`
+	RevertAfter string     `json:"revertAfter,omitempty"`
+	RevertAt    *time.Time `json:"revertAt,omitempty"`
+}
+
+//ServeHTTP returns the current level on GET and changes it on PUT.
+//The PUT body is a json object such as {"level":"debug","revertAfter":"10m"}.
+func (lc *LevelController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var p levelPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			writeLevelError(w, http.StatusBadRequest, fmt.Errorf("decoding body: %w", err))
+			return
+		}
+
+		//ParseLevel reads an empty level as info, a body without level
+		//or with a misspelled key must not reset the level
+		if p.Level == "" {
+			writeLevelError(w, http.StatusBadRequest, fmt.Errorf("level is required"))
+			return
+		}
+
+		level, err := zapcore.ParseLevel(p.Level)
+		if err != nil {
+			writeLevelError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		var revertAfter time.Duration
+		if p.RevertAfter != "" {
+			if revertAfter, err = time.ParseDuration(p.RevertAfter); err != nil {
+				writeLevelError(w, http.StatusBadRequest, fmt.Errorf("parsing revertAfter: %w", err))
+				return
+			}
+		}
+
+		lc.SetLevel(level, revertAfter)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeLevelError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	lc.mu.Lock()
+	p := levelPayload{Level: lc.level.Level().String()}
+	if !lc.revertAt.IsZero() {
+		revertAt := lc.revertAt
+		p.RevertAt = &revertAt
+	}
+	lc.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+func writeLevelError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
@@ -0,0 +1,124 @@
+package logger_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/Mahamadou828/tgs_with_golang/foundation/logger"
+)
+
+func TestLevelControllerHTTP(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+		level  zapcore.Level
+	}{
+		{"get", http.MethodGet, "", http.StatusOK, zapcore.InfoLevel},
+		{"put", http.MethodPut, `{"level":"debug"}`, http.StatusOK, zapcore.DebugLevel},
+		{"put with revert", http.MethodPut, `{"level":"warn","revertAfter":"1h"}`, http.StatusOK, zapcore.WarnLevel},
+		{"empty body", http.MethodPut, `{}`, http.StatusBadRequest, zapcore.InfoLevel},
+		{"misspelled key", http.MethodPut, `{"lvl":"debug"}`, http.StatusBadRequest, zapcore.InfoLevel},
+		{"empty level", http.MethodPut, `{"level":""}`, http.StatusBadRequest, zapcore.InfoLevel},
+		{"unknown level", http.MethodPut, `{"level":"verbose"}`, http.StatusBadRequest, zapcore.InfoLevel},
+		{"invalid revert", http.MethodPut, `{"level":"debug","revertAfter":"soon"}`, http.StatusBadRequest, zapcore.InfoLevel},
+		{"invalid json", http.MethodPut, `{"level":`, http.StatusBadRequest, zapcore.InfoLevel},
+		{"method not allowed", http.MethodPost, `{"level":"debug"}`, http.StatusMethodNotAllowed, zapcore.InfoLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lc := logger.NewLevelController(zap.NewAtomicLevelAt(zapcore.InfoLevel))
+
+			w := httptest.NewRecorder()
+			lc.ServeHTTP(w, httptest.NewRequest(tt.method, "/debug/loglevel", strings.NewReader(tt.body)))
+
+			if w.Code != tt.status {
+				t.Fatalf("expected the status %d, got %d: %s", tt.status, w.Code, w.Body)
+			}
+
+			if lvl := lc.Level(); lvl != tt.level {
+				t.Errorf("expected the level %s, got %s", tt.level, lvl)
+			}
+
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var p struct {
+				Level    string     `json:"level"`
+				RevertAt *time.Time `json:"revertAt"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+				t.Fatalf("decoding the response: %s", err)
+			}
+
+			if p.Level != tt.level.String() {
+				t.Errorf("expected the level %s in the response, got %s", tt.level, p.Level)
+			}
+			if strings.Contains(tt.body, "revertAfter") != (p.RevertAt != nil) {
+				t.Errorf("unexpected revertAt %v in the response", p.RevertAt)
+			}
+		})
+	}
+}
+
+//waitLevel waits for the level of the controller to become the expected one
+func waitLevel(t *testing.T, lc *logger.LevelController, level zapcore.Level) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for lc.Level() != level {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the level %s, got %s", level, lc.Level())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestLevelControllerRevert(t *testing.T) {
+	lc := logger.NewLevelController(zap.NewAtomicLevelAt(zapcore.InfoLevel))
+
+	lc.SetLevel(zapcore.DebugLevel, 20*time.Millisecond)
+	if lvl := lc.Level(); lvl != zapcore.DebugLevel {
+		t.Fatalf("expected the level debug, got %s", lvl)
+	}
+
+	waitLevel(t, lc, zapcore.InfoLevel)
+}
+
+func TestLevelControllerChangeKeepsBase(t *testing.T) {
+	lc := logger.NewLevelController(zap.NewAtomicLevelAt(zapcore.InfoLevel))
+
+	//The second change cancels the first revert but the level restored
+	//is still the one active before the first change.
+	lc.SetLevel(zapcore.DebugLevel, time.Hour)
+	lc.SetLevel(zapcore.WarnLevel, 20*time.Millisecond)
+
+	waitLevel(t, lc, zapcore.InfoLevel)
+}
+
+func TestLevelControllerChangeCancelsRevert(t *testing.T) {
+	lc := logger.NewLevelController(zap.NewAtomicLevelAt(zapcore.InfoLevel))
+
+	//A change without revert cancels the pending one.
+	lc.SetLevel(zapcore.DebugLevel, 20*time.Millisecond)
+	lc.SetLevel(zapcore.ErrorLevel, 0)
+
+	time.Sleep(100 * time.Millisecond)
+	if lvl := lc.Level(); lvl != zapcore.ErrorLevel {
+		t.Errorf("expected the level error to be kept, got %s", lvl)
+	}
+
+	//The base is taken again once no revert is pending.
+	lc.SetLevel(zapcore.DebugLevel, 20*time.Millisecond)
+	waitLevel(t, lc, zapcore.ErrorLevel)
+}
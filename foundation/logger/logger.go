@@ -1,27 +1,231 @@
+//Package logger provide a constructor for the zap logger shared by every
+//service of the project.
 package logger
 
 import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+//BuildDev is the build that produces a human readable console logger,
+//every other build produces a json logger.
+const BuildDev = "dev"
+
+//Config represent the logger options in a shape that can be filled
+//by the config package.
+type Config struct {
+	//Level is the minimum enabled level: debug, info, warn, error...
+	Level string
+	//Encoding of the main output, json or console. It defaults to console
+	//for the dev build and json for the others
+	Encoding string
+	//OutputPaths is a comma separated list of urls or file paths to write to,
+	//none only writes to the file
+	OutputPaths string
+	//SamplingInitial and SamplingThereafter configure the sampling per second,
+	//a zero SamplingInitial disables the sampling
+	SamplingInitial    int
+	SamplingThereafter int
+	//DisableStacktrace disables the stack traces of the error level logs
+	DisableStacktrace bool
+	//File is the path of a rotated file the logs are also written to,
+	//the file options are ignored when it's empty
+	File           string
+	FileEncoding   string
+	FileLevel      string
+	FileMaxSizeMB  int
+	FileMaxAge     time.Duration
+	FileMaxBackups int
+	FileCompress   bool
+}
+
+//settings hold everything needed to build the logger
+type settings struct {
+	config zap.Config
+	//sinks are teed with the core built from the config
+	sinks []Sink
+	//wraps are applied in order on the core writing to each output, below
+	//the sampling and the tee so every output keeps its own level and sampling.
+	//first is only set for the first output, so what's counted per entry
+	//such as the redactions is counted once.
+	wraps []func(core zapcore.Core, first bool) zapcore.Core
+}
+
+//Option customizes the construction of the logger
+type Option func(*settings) error
+
+//WithLevel sets the minimum enabled level
+func WithLevel(level string) Option {
+	return func(s *settings) error {
+		lvl, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return err
+		}
+
+		s.config.Level.SetLevel(lvl)
+
+		return nil
+	}
+}
+
+//WithOutputPaths sets the urls or file paths the logs are written to,
+//no paths means the logs are only written to the sinks.
+func WithOutputPaths(paths ...string) Option {
+	return func(s *settings) error {
+		s.config.OutputPaths = paths
+
+		return nil
+	}
+}
+
+//WithSampling caps the number of identical logs written per second, the
+//first initial entries are logged then one every thereafter entries.
+//A zero initial disables the sampling.
+func WithSampling(initial int, thereafter int) Option {
+	return func(s *settings) error {
+		if initial <= 0 {
+			s.config.Sampling = nil
+			return nil
+		}
+
+		s.config.Sampling = &zap.SamplingConfig{
+			Initial:    initial,
+			Thereafter: thereafter,
+		}
+
+		return nil
+	}
+}
+
+//WithEncoding sets the encoding of the main output, json or console. The
+//json encoding always uses the production keys expected by logfmt.
+func WithEncoding(encoding string) Option {
+	return func(s *settings) error {
+		switch encoding {
+		case EncodingJSON:
+			s.config.EncoderConfig = zap.NewProductionEncoderConfig()
+			s.config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		case EncodingConsole:
+		default:
+			return fmt.Errorf("unknown encoding %q", encoding)
+		}
+
+		s.config.Encoding = encoding
+
+		return nil
+	}
+}
+
+//WithStacktrace enables or disables the stack traces of the error level logs
+func WithStacktrace(enabled bool) Option {
+	return func(s *settings) error {
+		s.config.DisableStacktrace = !enabled
+		return nil
+	}
+}
+
+//WithConfig applies all the options read by the config package,
+//empty fields are ignored.
+func WithConfig(c Config) Option {
+	return func(s *settings) error {
+		opts := []Option{
+			WithSampling(c.SamplingInitial, c.SamplingThereafter),
+			WithStacktrace(!c.DisableStacktrace),
+		}
+
+		if c.Level != "" {
+			opts = append(opts, WithLevel(c.Level))
+		}
+
+		if c.Encoding != "" {
+			opts = append(opts, WithEncoding(c.Encoding))
+		}
+
+		switch c.OutputPaths {
+		case "":
+		case "none":
+			opts = append(opts, WithOutputPaths())
+		default:
+			opts = append(opts, WithOutputPaths(strings.Split(c.OutputPaths, ",")...))
+		}
+
+		if c.File != "" {
+			opts = append(opts, WithSinks(Sink{
+				Path:     c.File,
+				Encoding: c.FileEncoding,
+				Level:    c.FileLevel,
+				Rotation: Rotation{
+					MaxSizeMB:  c.FileMaxSizeMB,
+					MaxAge:     c.FileMaxAge,
+					MaxBackups: c.FileMaxBackups,
+					Compress:   c.FileCompress,
+				},
+			}))
+		}
+
+		for _, opt := range opts {
+			if err := opt(s); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
 //NewLogger creates a new instance of zap.Logger
-//For now it's creating a production level logger
-//Next step is to pass the env param and depending on him
-//Construct the appropriate logger
-func NewLogger(service string) (*zap.Logger, error) {
-	config := zap.NewProductionConfig()
+//The dev build creates a console logger, colored when it writes to a
+//terminal, every other build creates a production json logger. The
+//options are applied on top of the build defaults.
+func NewLogger(service string, build string, opts ...Option) (*zap.Logger, error) {
+	var s settings
+
+	switch build {
+	case BuildDev:
+		s.config = zap.NewDevelopmentConfig()
+	default:
+		s.config = zap.NewProductionConfig()
+	}
 
-	config.OutputPaths = []string{"stdout"}
-	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	config.DisableStacktrace = true
+	s.config.OutputPaths = []string{"stdout"}
+	s.config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 
-	config.InitialFields = map[string]interface{}{
+	s.config.InitialFields = map[string]interface{}{
 		"service": service,
+		"build":   build,
 	}
 
-	log, err := config.Build()
+	for _, opt := range opts {
+		if err := opt(&s); err != nil {
+			return nil, fmt.Errorf("applying logger option: %w", err)
+		}
+	}
+
+	if len(s.config.OutputPaths) == 0 && len(s.sinks) == 0 {
+		return nil, fmt.Errorf("at least one output path or sink is required")
+	}
+
+	//The colors are only written to a terminal, a pipe such as logfmt
+	//gets the plain levels.
+	if build == BuildDev && s.config.Encoding == EncodingConsole && isTerminal(s.config.OutputPaths...) {
+		s.config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
 
+	var zapOpts []zap.Option
+
+	//Stack traces are only captured for the error level and above,
+	//the development config would capture them from the warn level
+	if !s.config.DisableStacktrace {
+		zapOpts = append(zapOpts, zap.AddStacktrace(zapcore.ErrorLevel))
+	}
+
+	log, err := newZapLogger(s, zapOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -30,3 +234,102 @@ func NewLogger(service string) (*zap.Logger, error) {
 
 	return log, nil
 }
+
+//newZapLogger constructs the logger from the settings. The main output and every
+//sink get their own core, they are teed so an entry is checked against the
+//level and the sampling of each output.
+func newZapLogger(s settings, opts ...zap.Option) (*zap.Logger, error) {
+	var cores []zapcore.Core
+
+	if len(s.config.OutputPaths) > 0 {
+		ws, _, err := zap.Open(s.config.OutputPaths...)
+		if err != nil {
+			return nil, fmt.Errorf("opening output paths: %w", err)
+		}
+
+		var enc zapcore.Encoder
+		switch s.config.Encoding {
+		case EncodingConsole:
+			enc = zapcore.NewConsoleEncoder(s.config.EncoderConfig)
+		default:
+			enc = zapcore.NewJSONEncoder(s.config.EncoderConfig)
+		}
+
+		cores = append(cores, s.outputCore(zapcore.NewCore(enc, ws, s.config.Level), true))
+	}
+
+	for _, sink := range s.sinks {
+		core, err := buildSink(s.config, sink)
+		if err != nil {
+			return nil, fmt.Errorf("building sink %s: %w", sink.Path, err)
+		}
+		cores = append(cores, s.outputCore(core, len(cores) == 0))
+	}
+
+	errSink, _, err := zap.Open(s.config.ErrorOutputPaths...)
+	if err != nil {
+		return nil, fmt.Errorf("opening error output paths: %w", err)
+	}
+
+	zapOpts := []zap.Option{zap.ErrorOutput(errSink)}
+	if s.config.Development {
+		zapOpts = append(zapOpts, zap.Development())
+	}
+	if !s.config.DisableCaller {
+		zapOpts = append(zapOpts, zap.AddCaller())
+	}
+	zapOpts = append(zapOpts, opts...)
+
+	return zap.New(zapcore.NewTee(cores...), zapOpts...), nil
+}
+
+//outputCore completes the core writing to an output: the wraps such as the
+//redaction are applied first, then the sampling and the initial fields.
+func (s settings) outputCore(core zapcore.Core, first bool) zapcore.Core {
+	for _, wrap := range s.wraps {
+		core = wrap(core, first)
+	}
+
+	if s.config.Sampling != nil {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, s.config.Sampling.Initial, s.config.Sampling.Thereafter)
+	}
+
+	keys := make([]string, 0, len(s.config.InitialFields))
+	for k := range s.config.InitialFields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]zap.Field, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, zap.Any(k, s.config.InitialFields[k]))
+	}
+
+	return core.With(fields)
+}
+
+//isTerminal reports if every path is stdout or stderr attached to a terminal.
+func isTerminal(paths ...string) bool {
+	if len(paths) == 0 {
+		return false
+	}
+
+	for _, p := range paths {
+		var f *os.File
+		switch p {
+		case "stdout":
+			f = os.Stdout
+		case "stderr":
+			f = os.Stderr
+		default:
+			return false
+		}
+
+		info, err := f.Stat()
+		if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return false
+		}
+	}
+
+	return true
+}
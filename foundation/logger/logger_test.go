@@ -0,0 +1,80 @@
+package logger_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Mahamadou828/tgs_with_golang/foundation/logger"
+)
+
+//readLines returns the lines written in the file
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %s", path, err)
+	}
+
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestRedactorKeepsSamplingAndSinkLevels(t *testing.T) {
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "main.log")
+	sinkPath := filepath.Join(dir, "sink.log")
+
+	r := logger.NewRedactor()
+	r.AddValues("super-secret-value")
+
+	log, err := logger.NewLogger(
+		"test",
+		"prod",
+		logger.WithOutputPaths(mainPath),
+		logger.WithSampling(2, 1000),
+		logger.WithSinks(logger.Sink{Path: sinkPath, Level: "error"}),
+		logger.WithRedactor(r),
+	)
+	if err != nil {
+		t.Fatalf("creating the logger: %s", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		log.Info("sampled super-secret-value")
+	}
+	log.Error("failure super-secret-value")
+	if err := log.Sync(); err != nil {
+		t.Fatalf("syncing the logger: %s", err)
+	}
+
+	//The main output gets the construct log, the two first sampled logs
+	//and the error.
+	main := readLines(t, mainPath)
+	if len(main) != 4 {
+		t.Fatalf("main output: expected 4 lines, got %d:\n%s", len(main), strings.Join(main, "\n"))
+	}
+
+	var sampled int
+	for _, line := range main {
+		if strings.Contains(line, "sampled") {
+			sampled++
+		}
+	}
+	if sampled != 2 {
+		t.Errorf("main output: expected 2 sampled lines, got %d", sampled)
+	}
+
+	//The sink only gets the error.
+	sink := readLines(t, sinkPath)
+	if len(sink) != 1 || !strings.Contains(sink[0], "failure") {
+		t.Fatalf("sink output: expected the error line only, got:\n%s", strings.Join(sink, "\n"))
+	}
+
+	for _, line := range append(main, sink...) {
+		if strings.Contains(line, "super-secret-value") {
+			t.Errorf("secret written in the logs: %s", line)
+		}
+	}
+}
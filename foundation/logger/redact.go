@@ -0,0 +1,296 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+//Redacted is the value written in place of a sensitive value
+const Redacted = "[REDACTED]"
+
+//MinRedactedValueLen is the minimum length of a value registered at runtime,
+//shorter values would redact too many unrelated strings
+const MinRedactedValueLen = 4
+
+//DefaultSensitiveKeys are the field keys redacted by default, a field is
+//sensitive if its key contains one of them, case insensitively
+var DefaultSensitiveKeys = []string{"password", "token", "secret", "authorization"}
+
+//sensitivePatterns match well known credentials inside any string
+var sensitivePatterns = []*regexp.Regexp{
+	//JSON Web Tokens
+	regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	//AWS access key ids
+	regexp.MustCompile(`\b(?:AKIA|ASIA|AROA|AIDA)[0-9A-Z]{16}\b`),
+	//Bearer tokens of an authorization header
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]+=*`),
+}
+
+//Redactor removes the sensitive values from the log entries before
+//they are written. Use the WithRedactor option to install it.
+type Redactor struct {
+	keys []string
+
+	mu     sync.RWMutex
+	values []string
+
+	count uint64
+}
+
+//NewRedactor creates a redactor for the given sensitive keys,
+//the DefaultSensitiveKeys are used when no key is given.
+func NewRedactor(keys ...string) *Redactor {
+	if len(keys) == 0 {
+		keys = DefaultSensitiveKeys
+	}
+
+	r := Redactor{}
+	for _, k := range keys {
+		r.keys = append(r.keys, strings.ToLower(k))
+	}
+
+	return &r
+}
+
+//WithRedactor wraps the core of every output so every entry goes through the redactor
+func WithRedactor(r *Redactor) Option {
+	return func(s *settings) error {
+		s.wraps = append(s.wraps, r.wrap)
+		return nil
+	}
+}
+
+//AddValues registers values that must never be written, such as the secrets
+//returned by the ssm package. Values shorter than MinRedactedValueLen are ignored.
+func (r *Redactor) AddValues(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, v := range values {
+		if len(v) < MinRedactedValueLen {
+			continue
+		}
+		r.values = append(r.values, v)
+	}
+}
+
+//Count returns the number of redactions performed
+func (r *Redactor) Count() uint64 {
+	return atomic.LoadUint64(&r.count)
+}
+
+//Wrap returns a core redacting the entries before writing them in the given core.
+//The core must write to an output, a sampler or a tee wrapped by the redactor
+//would no longer be checked.
+func (r *Redactor) Wrap(core zapcore.Core) zapcore.Core {
+	return r.wrap(core, true)
+}
+
+//wrap returns a core redacting the entries, the redactions are only counted
+//when count is set so an entry written to several outputs is counted once.
+func (r *Redactor) wrap(core zapcore.Core, count bool) zapcore.Core {
+	return &redactCore{Core: core, r: r, count: count}
+}
+
+//isSensitiveKey reports if the key contains one of the sensitive keys
+func (r *Redactor) isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//redactString replaces the registered values and the sensitive patterns
+//found in the string, it returns the number of replacements.
+func (r *Redactor) redactString(s string) (string, int) {
+	n := 0
+
+	r.mu.RLock()
+	for _, v := range r.values {
+		if c := strings.Count(s, v); c > 0 {
+			s = strings.ReplaceAll(s, v, Redacted)
+			n += c
+		}
+	}
+	r.mu.RUnlock()
+
+	for _, p := range sensitivePatterns {
+		if c := len(p.FindAllStringIndex(s, -1)); c > 0 {
+			s = p.ReplaceAllString(s, Redacted)
+			n += c
+		}
+	}
+
+	return s, n
+}
+
+//redactValue redacts a value produced by a field encoder, maps and
+//slices are walked recursively. It returns the number of redactions.
+func (r *Redactor) redactValue(key string, v interface{}) (interface{}, int) {
+	if r.isSensitiveKey(key) {
+		return Redacted, 1
+	}
+
+	switch val := v.(type) {
+	case nil, bool, json.Number:
+		return v, 0
+	case string:
+		return r.redactString(val)
+	case map[string]interface{}:
+		n := 0
+		out := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			e, c := r.redactValue(k, e)
+			out[k] = e
+			n += c
+		}
+		return out, n
+	case []interface{}:
+		n := 0
+		out := make([]interface{}, len(val))
+		for i, e := range val {
+			e, c := r.redactValue("", e)
+			out[i] = e
+			n += c
+		}
+		return out, n
+	}
+
+	//The encoders keep the reflected values such as structs and typed maps
+	//as Go values, their json form is walked instead.
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Ptr, reflect.Interface:
+		if j, ok := toJSONValue(v); ok {
+			return r.redactValue(key, j)
+		}
+	}
+
+	return v, 0
+}
+
+//toJSONValue converts a value to its json form made of maps, slices,
+//strings, numbers, booleans and null.
+func toJSONValue(v interface{}) (interface{}, bool) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var out interface{}
+	if err := dec.Decode(&out); err != nil {
+		return nil, false
+	}
+
+	return out, true
+}
+
+//redactField returns the field with its sensitive values replaced and
+//the number of redactions.
+func (r *Redactor) redactField(f zapcore.Field) (zapcore.Field, int) {
+	if r.isSensitiveKey(f.Key) {
+		return zap.String(f.Key, Redacted), 1
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		if s, n := r.redactString(f.String); n > 0 {
+			return zap.String(f.Key, s), n
+		}
+	case zapcore.ByteStringType:
+		if s, n := r.redactString(string(f.Interface.([]byte))); n > 0 {
+			return zap.String(f.Key, s), n
+		}
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok && err != nil {
+			if s, n := r.redactString(err.Error()); n > 0 {
+				return zap.String(f.Key, s), n
+			}
+		}
+	case zapcore.StringerType:
+		if st, ok := f.Interface.(fmt.Stringer); ok && st != nil {
+			if s, n := r.redactString(st.String()); n > 0 {
+				return zap.String(f.Key, s), n
+			}
+		}
+	case zapcore.ReflectType, zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType:
+		//Encode the field to inspect its content, the original field
+		//is kept when nothing is sensitive
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		if v, n := r.redactValue("", enc.Fields[f.Key]); n > 0 {
+			return zap.Any(f.Key, v), n
+		}
+	}
+
+	return f, 0
+}
+
+//redactFields returns a copy of the fields with their sensitive values
+//replaced and the number of redactions.
+func (r *Redactor) redactFields(fields []zapcore.Field) ([]zapcore.Field, int) {
+	n := 0
+	out := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		var c int
+		out[i], c = r.redactField(f)
+		n += c
+	}
+
+	return out, n
+}
+
+//redactCore is a zapcore.Core redacting the entries of the core it wraps,
+//the redactions are added to the count of the redactor when count is set.
+type redactCore struct {
+	zapcore.Core
+	r     *Redactor
+	count bool
+}
+
+//add counts the redactions of the core.
+func (c *redactCore) add(n int) {
+	if c.count && n > 0 {
+		atomic.AddUint64(&c.r.count, uint64(n))
+	}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	fields, n := c.r.redactFields(fields)
+	c.add(n)
+
+	return &redactCore{Core: c.Core.With(fields), r: c.r, count: c.count}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var n, m, f int
+	ent.Message, n = c.r.redactString(ent.Message)
+	ent.Stack, m = c.r.redactString(ent.Stack)
+	fields, f = c.r.redactFields(fields)
+	c.add(n + m + f)
+
+	return c.Core.Write(ent, fields)
+}
@@ -0,0 +1,87 @@
+package logger_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/Mahamadou828/tgs_with_golang/foundation/logger"
+)
+
+func TestRedactorReflectedValues(t *testing.T) {
+	type DB struct {
+		User     string
+		Password string
+		DSN      string
+	}
+
+	tests := []struct {
+		name  string
+		field zap.Field
+	}{
+		{"struct", zap.Any("db", DB{User: "admin", Password: "pw-plain", DSN: "postgres://u:hunter22@h"})},
+		{"struct pointer", zap.Any("db", &DB{User: "admin", Password: "pw-plain", DSN: "postgres://u:hunter22@h"})},
+		{"map of strings", zap.Any("m", map[string]string{"user": "admin", "password": "pw-plain", "dsn": "postgres://u:hunter22@h"})},
+		{"slice of strings", zap.Any("s", []string{"admin", "hunter22"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "main.log")
+
+			r := logger.NewRedactor()
+			r.AddValues("hunter22")
+
+			log, err := logger.NewLogger("test", "prod", logger.WithOutputPaths(path), logger.WithRedactor(r))
+			if err != nil {
+				t.Fatalf("creating the logger: %s", err)
+			}
+
+			log.Info("config", tt.field)
+			if err := log.Sync(); err != nil {
+				t.Fatalf("syncing the logger: %s", err)
+			}
+
+			lines := readLines(t, path)
+			line := lines[len(lines)-1]
+
+			for _, secret := range []string{"pw-plain", "hunter22"} {
+				if strings.Contains(line, secret) {
+					t.Errorf("secret %s written in the logs: %s", secret, line)
+				}
+			}
+
+			if !strings.Contains(line, "admin") || !strings.Contains(line, logger.Redacted) {
+				t.Errorf("expected the field redacted and kept otherwise: %s", line)
+			}
+		})
+	}
+}
+
+func TestRedactorCountsEntriesOnce(t *testing.T) {
+	dir := t.TempDir()
+
+	r := logger.NewRedactor()
+	r.AddValues("hunter22")
+
+	log, err := logger.NewLogger(
+		"test",
+		"prod",
+		logger.WithOutputPaths(filepath.Join(dir, "main.log")),
+		logger.WithSinks(logger.Sink{Path: filepath.Join(dir, "sink.log")}),
+		logger.WithRedactor(r),
+	)
+	if err != nil {
+		t.Fatalf("creating the logger: %s", err)
+	}
+
+	log = log.With(zap.String("password", "pw-plain"))
+	log.Info("connecting with hunter22", zap.String("dsn", "postgres://u:hunter22@h"))
+
+	//The password of With, the value in the message and in the field.
+	if n := r.Count(); n != 3 {
+		t.Errorf("expected 3 redactions, got %d", n)
+	}
+}
@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+//Encodings supported by a sink
+const (
+	EncodingJSON    = "json"
+	EncodingConsole = "console"
+)
+
+//Sink is an additional output of the logger with its own encoding and level,
+//it receives the same entries as the main output.
+type Sink struct {
+	//Path is stdout, stderr or a file path
+	Path string
+	//Encoding is json or console, it defaults to json
+	Encoding string
+	//Level is the minimum level written to the sink, it can't be lower
+	//than the level of the logger. It defaults to the level of the logger
+	Level string
+	//Rotation is applied when the path is a file
+	Rotation Rotation
+}
+
+//Rotation configures the rotation of a file sink
+type Rotation struct {
+	//MaxSizeMB is the size in megabytes that triggers a rotation, it defaults to 100
+	MaxSizeMB int
+	//MaxAge is the maximum time to keep a rotated file, zero keeps them forever
+	MaxAge time.Duration
+	//MaxBackups is the maximum number of rotated files to keep, zero keeps them all
+	MaxBackups int
+	//Compress gzips the rotated files
+	Compress bool
+}
+
+//WithSinks adds outputs to the logger, for example a json file next to a
+//console output on stderr. Use WithOutputPaths without paths to only write
+//to the sinks.
+func WithSinks(sinks ...Sink) Option {
+	return func(s *settings) error {
+		for _, sink := range sinks {
+			if sink.Path == "" {
+				return fmt.Errorf("sink path is required")
+			}
+
+			switch sink.Encoding {
+			case "":
+			case EncodingJSON, EncodingConsole:
+			default:
+				return fmt.Errorf("unknown sink encoding %q", sink.Encoding)
+			}
+
+			if sink.Level != "" {
+				if _, err := zapcore.ParseLevel(sink.Level); err != nil {
+					return fmt.Errorf("sink %s: %w", sink.Path, err)
+				}
+			}
+		}
+
+		s.sinks = append(s.sinks, sinks...)
+
+		return nil
+	}
+}
+
+//buildSink creates the core writing to a single sink, the sampling and
+//the initial fields are added by the logger like for the main output
+func buildSink(config zap.Config, sink Sink) (zapcore.Core, error) {
+	var ws zapcore.WriteSyncer
+
+	switch sink.Path {
+	case "stdout", "stderr":
+		w, _, err := zap.Open(sink.Path)
+		if err != nil {
+			return nil, err
+		}
+		ws = w
+	default:
+		maxSize := sink.Rotation.MaxSizeMB
+		if maxSize <= 0 {
+			maxSize = 100
+		}
+
+		ws = zapcore.AddSync(&lumberjack.Logger{
+			Filename:   sink.Path,
+			MaxSize:    maxSize,
+			MaxAge:     int((sink.Rotation.MaxAge + 24*time.Hour - 1) / (24 * time.Hour)),
+			MaxBackups: sink.Rotation.MaxBackups,
+			Compress:   sink.Rotation.Compress,
+		})
+	}
+
+	var enc zapcore.Encoder
+
+	switch sink.Encoding {
+	case EncodingConsole:
+		//Colors are only kept on a terminal output
+		encCfg := config.EncoderConfig
+		if !isTerminal(sink.Path) {
+			encCfg.EncodeLevel = zapcore.CapitalLevelEncoder
+		}
+		enc = zapcore.NewConsoleEncoder(encCfg)
+	default:
+		//The json sinks always use the production keys expected by logfmt
+		encCfg := zap.NewProductionEncoderConfig()
+		encCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+		enc = zapcore.NewJSONEncoder(encCfg)
+	}
+
+	//The sink level is checked on top of the logger level so a runtime
+	//change of the logger level applies to every sink
+	minLevel := zapcore.DebugLevel
+	if sink.Level != "" {
+		lvl, err := zapcore.ParseLevel(sink.Level)
+		if err != nil {
+			return nil, err
+		}
+		minLevel = lvl
+	}
+
+	enabler := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return lvl >= minLevel && config.Level.Enabled(lvl)
+	})
+
+	return zapcore.NewCore(enc, ws, enabler), nil
+}
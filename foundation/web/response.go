@@ -0,0 +1,40 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+//Respond converts a Go value to JSON and sends it to the client.
+func Respond(ctx context.Context, w http.ResponseWriter, data any, statusCode int) error {
+	//Set the status code in the values of the request, the error is ignored
+	//since a handler run outside of an App has no values.
+	SetStatusCode(ctx, statusCode)
+
+	//If there is nothing to marshal then set status code and return.
+	if statusCode == http.StatusNoContent || data == nil {
+		w.WriteHeader(statusCode)
+		return nil
+	}
+
+	//Convert the response value to JSON.
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshaling response: %w", err)
+	}
+
+	//Set the content type and headers once we know marshaling has succeeded.
+	w.Header().Set("Content-Type", "application/json")
+
+	//Write the status code to the response.
+	w.WriteHeader(statusCode)
+
+	//Send the result back to the client.
+	if _, err := w.Write(jsonData); err != nil {
+		return fmt.Errorf("writing response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+//paramsKey is how the path parameters are stored/retrieved.
+type paramsKey struct{}
+
+//Param returns the value of a path parameter of the request, e.g. the id
+//of the route /users/:id.
+func Param(r *http.Request, key string) string {
+	params, _ := r.Context().Value(paramsKey{}).(map[string]string)
+	return params[key]
+}
+
+//Params returns all the path parameters of the request.
+func Params(r *http.Request) map[string]string {
+	params, _ := r.Context().Value(paramsKey{}).(map[string]string)
+	m := make(map[string]string, len(params))
+	for k, v := range params {
+		m[k] = v
+	}
+	return m
+}
+
+//route is a pattern registered for a method. A segment starting with : is a
+//parameter matching a single segment, a last segment starting with * is a
+//parameter matching the rest of the path.
+type route struct {
+	pattern  string
+	segments []string
+	handler  http.HandlerFunc
+	catchAll bool
+}
+
+//router dispatches the requests to the routes matching their method and path.
+type router struct {
+	routes map[string][]route
+
+	//notFound and methodNotAllowed answer the requests matching no route,
+	//the Allow header is set before methodNotAllowed is called.
+	notFound         http.HandlerFunc
+	methodNotAllowed http.HandlerFunc
+}
+
+func newRouter() *router {
+	return &router{
+		routes:   make(map[string][]route),
+		notFound: http.NotFound,
+		methodNotAllowed: func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		},
+	}
+}
+
+//handle registers a route, it panics on an invalid or duplicated pattern
+//like the http.ServeMux does.
+func (rt *router) handle(method string, pattern string, h http.HandlerFunc) {
+	if !strings.HasPrefix(pattern, "/") {
+		panic(fmt.Sprintf("web: pattern %q must start with /", pattern))
+	}
+
+	r := route{
+		pattern:  pattern,
+		segments: splitPath(pattern),
+		handler:  h,
+	}
+
+	for i, seg := range r.segments {
+		switch {
+		case seg == "*" || seg == ":":
+			panic(fmt.Sprintf("web: parameter without name in %q", pattern))
+		case strings.HasPrefix(seg, "*"):
+			if i != len(r.segments)-1 {
+				panic(fmt.Sprintf("web: catch-all %q must be the last segment of %q", seg, pattern))
+			}
+			r.catchAll = true
+		}
+	}
+
+	for _, existing := range rt.routes[method] {
+		if samePattern(existing.segments, r.segments) {
+			panic(fmt.Sprintf("web: %s %s conflicts with %s", method, pattern, existing.pattern))
+		}
+	}
+
+	routes := append(rt.routes[method], r)
+
+	//The most specific routes are tried first so the order of registration
+	//doesn't matter.
+	sort.SliceStable(routes, func(i, j int) bool {
+		return moreSpecific(routes[i], routes[j])
+	})
+
+	rt.routes[method] = routes
+}
+
+//ServeHTTP dispatches the request to the matching route, a path registered
+//for another method is answered with a 405 and the allowed methods.
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := splitPath(r.URL.Path)
+
+	for _, route := range rt.routes[r.Method] {
+		params, ok := route.match(segments)
+		if !ok {
+			continue
+		}
+
+		ctx := context.WithValue(r.Context(), paramsKey{}, params)
+		route.handler(w, r.WithContext(ctx))
+		return
+	}
+
+	var allowed []string
+	for method, routes := range rt.routes {
+		for _, route := range routes {
+			if _, ok := route.match(segments); ok {
+				allowed = append(allowed, method)
+				break
+			}
+		}
+	}
+
+	if len(allowed) == 0 {
+		rt.notFound(w, r)
+		return
+	}
+
+	sort.Strings(allowed)
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	rt.methodNotAllowed(w, r)
+}
+
+//match reports if the segments of a path match the route and returns the
+//values of its parameters.
+func (r route) match(segments []string) (map[string]string, bool) {
+	params := make(map[string]string)
+
+	for i, seg := range r.segments {
+		if strings.HasPrefix(seg, "*") {
+			params[seg[1:]] = strings.Join(segments[i:], "/")
+			return params, true
+		}
+
+		if i >= len(segments) {
+			return nil, false
+		}
+
+		switch {
+		case strings.HasPrefix(seg, ":"):
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[seg[1:]] = segments[i]
+		case seg != segments[i]:
+			return nil, false
+		}
+	}
+
+	if len(segments) != len(r.segments) {
+		return nil, false
+	}
+
+	return params, true
+}
+
+//moreSpecific reports if the route a must be tried before b. The segments
+//are compared from the left, the first one that differs decides: a literal
+//beats a parameter which beats a catch-all. For example /users/:id is
+//tried before /:kind/new and /files/:name before /files/*path.
+func moreSpecific(a route, b route) bool {
+	for i := 0; i < len(a.segments) && i < len(b.segments); i++ {
+		ra, rb := segmentRank(a.segments[i]), segmentRank(b.segments[i])
+		if ra != rb {
+			return ra < rb
+		}
+	}
+
+	//A catch-all matching an empty rest comes after the route without it,
+	//like /files/*path after /files.
+	return !a.catchAll && b.catchAll
+}
+
+//segmentRank orders the kinds of segments from the most specific one.
+func segmentRank(seg string) int {
+	switch {
+	case strings.HasPrefix(seg, "*"):
+		return 2
+	case strings.HasPrefix(seg, ":"):
+		return 1
+	}
+
+	return 0
+}
+
+//splitPath splits a path in segments, the leading and trailing slashes
+//are ignored so /users/ matches /users.
+func splitPath(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+
+	return strings.Split(p, "/")
+}
+
+//samePattern reports if two patterns match the same paths, whatever
+//the names of their parameters.
+func samePattern(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		switch {
+		case strings.HasPrefix(a[i], ":") && strings.HasPrefix(b[i], ":"):
+		case strings.HasPrefix(a[i], "*") && strings.HasPrefix(b[i], "*"):
+		case a[i] != b[i]:
+			return false
+		}
+	}
+
+	return true
+}
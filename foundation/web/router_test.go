@@ -0,0 +1,129 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+//routeHandler answers with the pattern of the route and its parameters.
+func routeHandler(pattern string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %v", pattern, Params(r))
+	}
+}
+
+func TestRouter(t *testing.T) {
+	routes := []struct {
+		method  string
+		pattern string
+	}{
+		{http.MethodGet, "/"},
+		{http.MethodGet, "/:kind/new"},
+		{http.MethodGet, "/users/:id"},
+		{http.MethodGet, "/users/me"},
+		{http.MethodPut, "/users/:id"},
+		{http.MethodGet, "/files/*path"},
+		{http.MethodGet, "/files/:name"},
+		{http.MethodGet, "/files/readme"},
+		{http.MethodGet, "/docs/"},
+		{http.MethodGet, "/docs/*rest"},
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+		allow  string
+	}{
+		{http.MethodGet, "/", http.StatusOK, "/ map[]", ""},
+		{http.MethodGet, "/users/42", http.StatusOK, "/users/:id map[id:42]", ""},
+		{http.MethodGet, "/users/42/", http.StatusOK, "/users/:id map[id:42]", ""},
+		{http.MethodGet, "/users/me", http.StatusOK, "/users/me map[]", ""},
+		{http.MethodGet, "/users/new", http.StatusOK, "/users/:id map[id:new]", ""},
+		{http.MethodGet, "/posts/new", http.StatusOK, "/:kind/new map[kind:posts]", ""},
+		{http.MethodGet, "/files/readme", http.StatusOK, "/files/readme map[]", ""},
+		{http.MethodGet, "/files/a.txt", http.StatusOK, "/files/:name map[name:a.txt]", ""},
+		{http.MethodGet, "/files/a/b/c.txt", http.StatusOK, "/files/*path map[path:a/b/c.txt]", ""},
+		{http.MethodGet, "/files", http.StatusOK, "/files/*path map[path:]", ""},
+		{http.MethodGet, "/docs", http.StatusOK, "/docs/ map[]", ""},
+		{http.MethodGet, "/docs/intro", http.StatusOK, "/docs/*rest map[rest:intro]", ""},
+		{http.MethodGet, "/users", http.StatusNotFound, "", ""},
+		{http.MethodGet, "/users/42/posts", http.StatusNotFound, "", ""},
+		{http.MethodPut, "/users/42", http.StatusOK, "/users/:id map[id:42]", ""},
+		{http.MethodDelete, "/users/42", http.StatusMethodNotAllowed, "", "GET, PUT"},
+		{http.MethodPost, "/files/a", http.StatusMethodNotAllowed, "", "GET"},
+	}
+
+	//The routes are registered in both orders, the precedence must not
+	//depend on it.
+	for _, reversed := range []bool{false, true} {
+		rt := newRouter()
+		for i := range routes {
+			r := routes[i]
+			if reversed {
+				r = routes[len(routes)-1-i]
+			}
+			rt.handle(r.method, r.pattern, routeHandler(r.pattern))
+		}
+
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s %s reversed=%t", tt.method, tt.path, reversed), func(t *testing.T) {
+				w := httptest.NewRecorder()
+				rt.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+				if w.Code != tt.status {
+					t.Fatalf("expected the status %d, got %d: %s", tt.status, w.Code, w.Body)
+				}
+				if tt.status == http.StatusOK && w.Body.String() != tt.body {
+					t.Errorf("expected %q, got %q", tt.body, w.Body)
+				}
+				if allow := w.Header().Get("Allow"); allow != tt.allow {
+					t.Errorf("expected the Allow header %q, got %q", tt.allow, allow)
+				}
+			})
+		}
+	}
+}
+
+func TestRouterPanics(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		pattern  string
+	}{
+		{"relative pattern", "", "users"},
+		{"parameter without name", "", "/users/:"},
+		{"catch-all without name", "", "/files/*"},
+		{"catch-all not last", "", "/files/*path/raw"},
+		{"duplicate", "/users/:id", "/users/:id"},
+		{"duplicate with other names", "/users/:id", "/users/:name"},
+		{"duplicate trailing slash", "/users", "/users/"},
+		{"duplicate catch-all", "/files/*path", "/files/*rest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := newRouter()
+			if tt.existing != "" {
+				rt.handle(http.MethodGet, tt.existing, routeHandler(tt.existing))
+			}
+
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected a panic registering %s", tt.pattern)
+				}
+			}()
+
+			rt.handle(http.MethodGet, tt.pattern, routeHandler(tt.pattern))
+		})
+	}
+}
+
+func TestRouterSamePatternOtherMethod(t *testing.T) {
+	rt := newRouter()
+	rt.handle(http.MethodGet, "/users/:id", routeHandler("get"))
+	rt.handle(http.MethodPut, "/users/:id", routeHandler("put"))
+}
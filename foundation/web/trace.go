@@ -0,0 +1,153 @@
+package web
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//Headers carrying the trace id of a request, the trace id of an incoming
+//request is reused so a request can be followed across services. A trace id
+//is always 32 lowercase hexadecimal characters, the trace id of a traceparent.
+//For more details on traceparent, see: https://www.w3.org/TR/trace-context/
+const (
+	TraceParentHeader = "Traceparent"
+	RequestIDHeader   = "X-Request-ID"
+)
+
+//emptyTraceID is returned when no trace id is stored in the context.
+const emptyTraceID = "00000000000000000000000000000000"
+
+//trace is the trace context of a request.
+type trace struct {
+	traceID string
+	spanID  string
+	flags   string
+}
+
+//newTrace reads the trace context of the request from the traceparent
+//header then the X-Request-ID header, a new trace id is generated when none
+//is valid. An X-Request-ID is only reused when it's a UUID or a trace id so
+//the trace ids of the logs keep a single format. The span id always
+//identifies the current service.
+func newTrace(r *http.Request) trace {
+	t := trace{
+		spanID: randomHex(8),
+		flags:  "00",
+	}
+
+	if traceID, flags, ok := parseTraceParent(r.Header.Get(TraceParentHeader)); ok {
+		t.traceID = traceID
+		t.flags = flags
+		return t
+	}
+
+	if traceID, ok := parseRequestID(r.Header.Get(RequestIDHeader)); ok {
+		t.traceID = traceID
+		return t
+	}
+
+	t.traceID = newTraceID()
+	return t
+}
+
+//setHeaders echoes the trace context in the response.
+func (t trace) setHeaders(h http.Header) {
+	h.Set(RequestIDHeader, t.traceID)
+	h.Set(TraceParentHeader, "00-"+t.traceID+"-"+t.spanID+"-"+t.flags)
+}
+
+//parseTraceParent returns the trace id and the flags of a traceparent
+//header: version-traceid-parentid-flags.
+func parseTraceParent(s string) (traceID string, flags string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 {
+		return "", "", false
+	}
+
+	version, traceID, parentID, flags := parts[0], strings.ToLower(parts[1]), parts[2], parts[3]
+
+	//Version ff is invalid, the version 00 has exactly four parts.
+	switch {
+	case !isHex(version, 2), version == "ff", version == "00" && len(parts) != 4:
+		return "", "", false
+	case !isHex(traceID, 32), traceID == strings.Repeat("0", 32):
+		return "", "", false
+	case !isHex(parentID, 16), parentID == strings.Repeat("0", 16):
+		return "", "", false
+	case !isHex(flags, 2):
+		return "", "", false
+	}
+
+	return traceID, flags, true
+}
+
+//parseRequestID returns the trace id of an incoming X-Request-ID, it must
+//be a UUID or 32 hexadecimal characters, any other value isn't trusted.
+func parseRequestID(id string) (string, bool) {
+	if len(id) == 36 {
+		for _, i := range []int{8, 13, 18, 23} {
+			if id[i] != '-' {
+				return "", false
+			}
+		}
+		id = strings.ReplaceAll(id, "-", "")
+	}
+
+	id = strings.ToLower(id)
+	if !isHex(id, 32) || id == emptyTraceID {
+		return "", false
+	}
+
+	return id, true
+}
+
+//isHex reports if s is made of n lowercase hexadecimal characters.
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+//newTraceID generates a random trace id, the W3C forbids an all zero id.
+func newTraceID() string {
+	for {
+		if id := randomHex(16); id != emptyTraceID {
+			return id
+		}
+	}
+}
+
+//randomHex generates n random bytes encoded in hexadecimal.
+func randomHex(n int) string {
+	return hex.EncodeToString(randomBytes(n))
+}
+
+//randomBytes generates n random bytes. The random source of the OS only
+//fails when it's unavailable, the clock is used then so the ids stay unique
+//enough to follow a request.
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err == nil {
+		return b
+	}
+
+	for i := 0; i < n; i += 8 {
+		var chunk [8]byte
+		binary.BigEndian.PutUint64(chunk[:], uint64(time.Now().UnixNano())+uint64(i))
+		copy(b[i:], chunk[:])
+	}
+
+	return b
+}
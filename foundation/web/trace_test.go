@@ -0,0 +1,137 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	testTraceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testParentID = "00f067aa0ba902b7"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		traceID string
+		flags   string
+		ok      bool
+	}{
+		{"valid", "00-" + testTraceID + "-" + testParentID + "-01", testTraceID, "01", true},
+		{"surrounding spaces", " 00-" + testTraceID + "-" + testParentID + "-00 ", testTraceID, "00", true},
+		{"upper case trace id", "00-" + strings.ToUpper(testTraceID) + "-" + testParentID + "-01", testTraceID, "01", true},
+		{"future version with more parts", "01-" + testTraceID + "-" + testParentID + "-01-extra", testTraceID, "01", true},
+		{"empty", "", "", "", false},
+		{"version 00 with more parts", "00-" + testTraceID + "-" + testParentID + "-01-extra", "", "", false},
+		{"version ff", "ff-" + testTraceID + "-" + testParentID + "-01", "", "", false},
+		{"invalid version", "0g-" + testTraceID + "-" + testParentID + "-01", "", "", false},
+		{"missing part", "00-" + testTraceID + "-" + testParentID, "", "", false},
+		{"short trace id", "00-" + testTraceID[:30] + "-" + testParentID + "-01", "", "", false},
+		{"zero trace id", "00-" + strings.Repeat("0", 32) + "-" + testParentID + "-01", "", "", false},
+		{"zero parent id", "00-" + testTraceID + "-" + strings.Repeat("0", 16) + "-01", "", "", false},
+		{"invalid parent id", "00-" + testTraceID + "-" + "00f067aa0ba902bz" + "-01", "", "", false},
+		{"invalid flags", "00-" + testTraceID + "-" + testParentID + "-1", "", "", false},
+		{"injection", "00-" + testTraceID + "\r\nX-Evil: 1-" + testParentID + "-01", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traceID, flags, ok := parseTraceParent(tt.header)
+			if ok != tt.ok || traceID != tt.traceID || flags != tt.flags {
+				t.Errorf("expected (%q, %q, %t), got (%q, %q, %t)", tt.traceID, tt.flags, tt.ok, traceID, flags, ok)
+			}
+		})
+	}
+}
+
+func TestParseRequestID(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		traceID string
+		ok      bool
+	}{
+		{"uuid", "4bf92f35-77b3-4da6-a3ce-929d0e0e4736", testTraceID, true},
+		{"upper case uuid", "4BF92F35-77B3-4DA6-A3CE-929D0E0E4736", testTraceID, true},
+		{"trace id", testTraceID, testTraceID, true},
+		{"empty", "", "", false},
+		{"zero uuid", "00000000-0000-0000-0000-000000000000", "", false},
+		{"misplaced dashes", "4bf92f3577-b3-4da6-a3ce-929d0e0e4736", "", false},
+		{"not hex", "request-42", "", false},
+		{"too long", testTraceID + "00", "", false},
+		{"injection", "4bf92f35-77b3-4da6-a3ce-929d0e0e\r\n36", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traceID, ok := parseRequestID(tt.id)
+			if ok != tt.ok || traceID != tt.traceID {
+				t.Errorf("expected (%q, %t), got (%q, %t)", tt.traceID, tt.ok, traceID, ok)
+			}
+		})
+	}
+}
+
+func TestTraceHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		traceID string
+		flags   string
+	}{
+		{"traceparent", map[string]string{TraceParentHeader: "00-" + testTraceID + "-" + testParentID + "-01"}, testTraceID, "01"},
+		{"traceparent first", map[string]string{TraceParentHeader: "00-" + testTraceID + "-" + testParentID + "-01", RequestIDHeader: "11111111-2222-4333-8444-555555555555"}, testTraceID, "01"},
+		{"request id", map[string]string{RequestIDHeader: "4bf92f35-77b3-4da6-a3ce-929d0e0e4736"}, testTraceID, "00"},
+		{"invalid request id", map[string]string{RequestIDHeader: "<script>"}, "", "00"},
+		{"no header", nil, "", "00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			tr := newTrace(r)
+			if !isHex(tr.traceID, 32) || tr.traceID == emptyTraceID {
+				t.Fatalf("expected a trace id of 32 hexadecimal characters, got %q", tr.traceID)
+			}
+			if tt.traceID != "" && tr.traceID != tt.traceID {
+				t.Errorf("expected the trace id %s, got %s", tt.traceID, tr.traceID)
+			}
+			if !isHex(tr.spanID, 16) || tr.spanID == testParentID {
+				t.Errorf("expected a new span id, got %q", tr.spanID)
+			}
+
+			h := make(http.Header)
+			tr.setHeaders(h)
+
+			if got := h.Get(RequestIDHeader); got != tr.traceID {
+				t.Errorf("expected the X-Request-ID %s, got %s", tr.traceID, got)
+			}
+
+			want := "00-" + tr.traceID + "-" + tr.spanID + "-" + tt.flags
+			if got := h.Get(TraceParentHeader); got != want {
+				t.Errorf("expected the traceparent %s, got %s", want, got)
+			}
+			if traceID, _, ok := parseTraceParent(h.Get(TraceParentHeader)); !ok || traceID != tr.traceID {
+				t.Errorf("the echoed traceparent isn't valid: %s", h.Get(TraceParentHeader))
+			}
+		})
+	}
+}
+
+func TestNewTraceIDUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id := newTraceID()
+		if seen[id] {
+			t.Fatalf("duplicate trace id %s", id)
+		}
+		seen[id] = true
+	}
+}
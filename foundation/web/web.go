@@ -0,0 +1,179 @@
+//Package web provide a small web framework extension shared by every
+//service of the project. Handlers receive the request context and return
+//an error, so errors are handled in one place by a middleware.
+package web
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Mahamadou828/tgs_with_golang/foundation/logger"
+)
+
+//A Handler is a type that handles a http request within our own little mini
+//framework.
+type Handler func(ctx context.Context, w http.ResponseWriter, r *http.Request) error
+
+//App is the entrypoint into our application and what configures our context
+//object for each of our http handlers. It implements http.Handler so it can
+//be given to a http.Server.
+type App struct {
+	router   *router
+	shutdown chan os.Signal
+	mw       []Middleware
+}
+
+//NewApp creates an App value that handle a set of routes for the application.
+//The middlewares are run for every route, before the group and route ones.
+func NewApp(shutdown chan os.Signal, mw ...Middleware) *App {
+	a := App{
+		router:   newRouter(),
+		shutdown: shutdown,
+		mw:       mw,
+	}
+
+	//The requests matching no route go through the app middlewares too,
+	//so they are logged, counted and traced like the others.
+	a.router.notFound = a.wrap(notFound, nil)
+	a.router.methodNotAllowed = a.wrap(methodNotAllowed, nil)
+
+	return &a
+}
+
+//SignalShutdown is used to gracefully shut down the app when an integrity
+//issue is identified. The signal is dropped when a shutdown is already pending.
+func (a *App) SignalShutdown() {
+	select {
+	case a.shutdown <- syscall.SIGTERM:
+	default:
+	}
+}
+
+//ServeHTTP implements the http.Handler interface.
+func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.router.ServeHTTP(w, r)
+}
+
+//Handle sets a handler function for a given HTTP method and path pair
+//to the application server mux. The group is a path prefix such as the
+//version of the api, e.g. Handle(http.MethodGet, "v1", "/users/:id", h).
+//The route middlewares are run after the app ones.
+func (a *App) Handle(method string, group string, path string, handler Handler, mw ...Middleware) {
+	a.handle(method, joinPath(group, path), handler, mw)
+}
+
+//Group creates a group of routes sharing a path prefix and middlewares,
+//such as a version of the api.
+func (a *App) Group(prefix string, mw ...Middleware) *Group {
+	return &Group{
+		app:    a,
+		prefix: joinPath(prefix, ""),
+		mw:     mw,
+	}
+}
+
+//handle wraps the handler with the route then the app middlewares and
+//registers it in the router.
+func (a *App) handle(method string, pattern string, handler Handler, mw []Middleware) {
+	a.router.handle(method, pattern, a.wrap(handler, mw))
+}
+
+//wrap wraps the handler with the route then the app middlewares and turns
+//it into a http handler setting up the values and the trace of the request.
+func (a *App) wrap(handler Handler, mw []Middleware) http.HandlerFunc {
+	handler = wrapMiddleware(mw, handler)
+	handler = wrapMiddleware(a.mw, handler)
+
+	h := func(w http.ResponseWriter, r *http.Request) {
+		//Set the context with the required values to process the request,
+		//the logs written with the logger of the context carry the trace id.
+		t := newTrace(r)
+		t.setHeaders(w.Header())
+
+		v := Values{
+			TraceID: t.traceID,
+			Now:     time.Now(),
+		}
+		ctx := context.WithValue(r.Context(), key, &v)
+		ctx = logger.WithTraceID(ctx, t.traceID, t.spanID)
+
+		err := handler(ctx, w, r.WithContext(ctx))
+		if err == nil {
+			return
+		}
+
+		//A handler asked for the service to restart.
+		if IsShutdown(err) {
+			a.SignalShutdown()
+			return
+		}
+
+		//Any other error reaching this point wasn't handled by a middleware,
+		//the client still needs an answer.
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+
+	return h
+}
+
+//notFound answers the requests whose path matches no route.
+func notFound(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	SetStatusCode(ctx, http.StatusNotFound)
+	http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	return nil
+}
+
+//methodNotAllowed answers the requests whose path only matches routes of
+//other methods, the router already set the Allow header.
+func methodNotAllowed(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	SetStatusCode(ctx, http.StatusMethodNotAllowed)
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	return nil
+}
+
+//Group is a set of routes sharing a path prefix and middlewares.
+type Group struct {
+	app    *App
+	prefix string
+	mw     []Middleware
+}
+
+//Handle sets a handler function for a given HTTP method and path pair
+//relative to the prefix of the group. The middlewares of the group are run
+//after the app ones and before the route ones.
+func (g *Group) Handle(method string, path string, handler Handler, mw ...Middleware) {
+	all := make([]Middleware, 0, len(g.mw)+len(mw))
+	all = append(all, g.mw...)
+	all = append(all, mw...)
+
+	g.app.handle(method, joinPath(g.prefix, path), handler, all)
+}
+
+//Group creates a sub group of routes, its middlewares are run after the
+//ones of the parent group.
+func (g *Group) Group(prefix string, mw ...Middleware) *Group {
+	all := make([]Middleware, 0, len(g.mw)+len(mw))
+	all = append(all, g.mw...)
+	all = append(all, mw...)
+
+	return &Group{
+		app:    g.app,
+		prefix: joinPath(g.prefix, prefix),
+		mw:     all,
+	}
+}
+
+//joinPath joins a prefix and a path into an absolute path.
+func joinPath(prefix string, p string) string {
+	joined := path.Join("/", prefix, p)
+	if strings.HasSuffix(p, "/") && joined != "/" {
+		joined += "/"
+	}
+
+	return joined
+}
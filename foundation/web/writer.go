@@ -0,0 +1,99 @@
+package web
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+//ResponseWriter records the status code and the number of bytes written to
+//a response, it's used by the middlewares logging the requests. The
+//http.Flusher and http.Hijacker interfaces of the wrapped writer are kept
+//so streaming and websocket handlers keep working.
+type ResponseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+var (
+	_ http.Flusher  = (*ResponseWriter)(nil)
+	_ http.Hijacker = (*ResponseWriter)(nil)
+)
+
+//NewResponseWriter wraps a response writer, a writer already wrapped is
+//returned as is so the status is recorded once.
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	if rw, ok := w.(*ResponseWriter); ok {
+		return rw
+	}
+
+	return &ResponseWriter{ResponseWriter: w}
+}
+
+//WriteHeader records the status code and sends it.
+func (rw *ResponseWriter) WriteHeader(statusCode int) {
+	if rw.status == 0 {
+		rw.status = statusCode
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+//Write records the number of bytes written, a write without a status
+//code sends a 200 like the http package does.
+func (rw *ResponseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += n
+	return n, err
+}
+
+//Flush implements the http.Flusher interface when the wrapped writer does.
+func (rw *ResponseWriter) Flush() {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+//Hijack implements the http.Hijacker interface when the wrapped writer does.
+func (rw *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("web: the response writer doesn't support hijacking")
+	}
+
+	//The connection now belongs to the handler, it's reported as switching
+	//protocols like a websocket upgrade.
+	if rw.status == 0 {
+		rw.status = http.StatusSwitchingProtocols
+	}
+
+	return h.Hijack()
+}
+
+//Unwrap returns the wrapped writer, it's used by http.ResponseController.
+func (rw *ResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+//Status returns the status code sent, 200 when the handler didn't write
+//anything since it's what the http package sends.
+func (rw *ResponseWriter) Status() int {
+	if rw.status == 0 {
+		return http.StatusOK
+	}
+	return rw.status
+}
+
+//Bytes returns the number of bytes of the body written.
+func (rw *ResponseWriter) Bytes() int {
+	return rw.bytes
+}